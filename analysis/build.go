@@ -0,0 +1,262 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrNodeBudget is returned when building a tree would exceed Options.MaxNodes.
+	ErrNodeBudget = errors.New("node budget exceeded")
+	// ErrMemoryBudget is returned when building a tree would exceed Options.MaxMemory.
+	ErrMemoryBudget = errors.New("memory budget exceeded")
+)
+
+// Options controls how a tree is built. The zero value builds serially with no budgets.
+type Options struct {
+	// Workers is the number of subtrees that may be built concurrently. Zero or
+	// one builds serially.
+	Workers int
+	// MaxNodes is the largest number of nodes the tree may have. Zero means no limit.
+	MaxNodes int
+	// MaxMemory is the largest estimated number of bytes the nodes of the tree may
+	// hold, not counting the dataset they share. Zero means no limit.
+	MaxMemory int64
+	// MaxDepth is the deepest a node may be, counting the root as depth zero. Nodes
+	// at that depth become leaves as though no attributes remained, so a MaxDepth of
+	// one builds a stump. Zero means no limit.
+	MaxDepth int
+	// ClassWeights multiplies the weight of every example of a target so that rare
+	// targets count for more in entropy, gain and leaf labels. Targets that are not
+	// in the map keep their weight.
+	ClassWeights map[string]float64
+	// BalancedClassWeights weighs each target inversely to its total weight so that
+	// every target counts equally, in place of ClassWeights.
+	BalancedClassWeights bool
+	// BinarySplits lets a nominal attribute split its values into two sets when
+	// that has a better gain ratio than one branch per value, as ordinal attributes
+	// may split their order in two. The attribute then remains available to divide
+	// either set further.
+	BinarySplits bool
+	// Costs labels each leaf with the target of least expected cost instead of the
+	// most common target. It must have costs for every target of the dataset.
+	Costs *CostMatrix
+	// Criterion measures the impurity of a real target that splits reduce, Variance
+	// when empty. It does not apply to datasets with Targets.
+	Criterion Criterion
+}
+
+// BuildTreeContext builds a tree, building sibling subtrees concurrently when
+// opts.Workers allows. The resulting tree is the same however many workers are
+// used. Building stops with the context's error when it is cancelled or its
+// deadline passes, and with ErrNodeBudget or ErrMemoryBudget when a budget runs out.
+func BuildTreeContext(ctx context.Context, sample parse.Sample, opts Options) (Node, error) {
+	dataset, err := NewDataset(sample)
+	if err != nil {
+		return Node{}, fmt.Errorf("building analyzed sample from parse sample: %w", err)
+	}
+	return dataset.BuildTree(ctx, opts)
+}
+
+// BuildTree builds a tree over every row of the dataset as BuildTreeContext does.
+func (d *Dataset) BuildTree(ctx context.Context, opts Options) (Node, error) {
+	b, d, err := d.newBuilder(ctx, opts)
+	if err != nil {
+		return Node{}, err
+	}
+	rootNode, err := b.build(newSubset(d, d.allRows(), d.nominalAttributes(), splitOptions{
+		binarySplits: opts.BinarySplits,
+		criterion:    opts.Criterion,
+	}), "", nil)
+	if err != nil {
+		return rootNode, fmt.Errorf("building root node: %w", err)
+	}
+	return rootNode, nil
+}
+
+// newBuilder validates the options for building a tree over the dataset, returning
+// a builder for them and the dataset with its rows weighted by any class weights.
+func (d *Dataset) newBuilder(ctx context.Context, opts Options) (*builder, *Dataset, error) {
+	if opts.MaxDepth < 0 {
+		return nil, nil, fmt.Errorf("maximum depth %d is negative", opts.MaxDepth)
+	}
+	switch opts.Criterion {
+	case "", Variance, MAE:
+	default:
+		return nil, nil, fmt.Errorf("unknown criterion %s, expected %s or %s", opts.Criterion, Variance, MAE)
+	}
+	if d.regression() && (len(opts.ClassWeights) > 0 || opts.BalancedClassWeights || opts.Costs != nil) {
+		return nil, nil, fmt.Errorf("class weights and costs do not apply to the real target %s", d.RealTarget)
+	}
+	d, err := d.withClassWeights(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Costs != nil {
+		if err = opts.Costs.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if err = opts.Costs.covers(d.Targets); err != nil {
+			return nil, nil, err
+		}
+	}
+	b := &builder{ctx: ctx, opts: opts}
+	if opts.Workers > 1 {
+		// the calling goroutine is one of the workers
+		b.workers = make(chan struct{}, opts.Workers-1)
+	}
+
+	return b, d, nil
+}
+
+type builder struct {
+	ctx  context.Context
+	opts Options
+	// workers holds a token for each extra goroutine building a subtree; nil builds serially
+	workers chan struct{}
+	nodes   int64
+	memory  int64
+}
+
+// leafLabel returns the label of a leaf with the given target counts, which would
+// otherwise be labelled with the given target.
+func (b *builder) leafLabel(targetCounts map[string]float64, target string) string {
+	if b.opts.Costs == nil {
+		return target
+	}
+
+	return b.opts.Costs.MinCostTarget(targetCounts)
+}
+
+// withClassWeights returns a dataset sharing d's columns whose row weights are
+// scaled by the class weights of the options, or d itself when there are none.
+func (d *Dataset) withClassWeights(opts Options) (*Dataset, error) {
+	classWeights := opts.ClassWeights
+	if opts.BalancedClassWeights {
+		classWeights = d.BalancedClassWeights()
+	}
+	if len(classWeights) == 0 {
+		return d, nil
+	}
+	multipliers := make([]float64, len(d.Targets))
+	for code, target := range d.Targets {
+		multipliers[code] = 1
+		if w, ok := classWeights[target]; ok {
+			if !(w > 0) {
+				return nil, fmt.Errorf("class weight %v of %s is not positive", w, target)
+			}
+			multipliers[code] = w
+		}
+	}
+	for target := range classWeights {
+		if !d.Targets.IsValid(target) {
+			return nil, fmt.Errorf("class weight given for unknown target %s", target)
+		}
+	}
+	weighted := *d
+	weighted.weights = make([]float64, len(d.weights))
+	for row, w := range d.weights {
+		weighted.weights[row] = w * multipliers[d.targets[row]]
+	}
+
+	return &weighted, nil
+}
+
+// BalancedClassWeights returns a weight for each target present in the dataset
+// that makes its total weight equal to that of every other present target, while
+// keeping the overall total weight the same.
+func (d *Dataset) BalancedClassWeights() map[string]float64 {
+	counts := d.targetCounts(d.allRows())
+	var total float64
+	var present int
+	for _, c := range counts {
+		if c > 0 {
+			total += c
+			present++
+		}
+	}
+	weights := make(map[string]float64, present)
+	for code, c := range counts {
+		if c > 0 {
+			weights[d.Targets[code]] = total / (float64(present) * c)
+		}
+	}
+
+	return weights
+}
+
+// admit checks for cancellation and charges the node for s against the budgets.
+func (b *builder) admit(s Sample) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	if nodes := atomic.AddInt64(&b.nodes, 1); b.opts.MaxNodes > 0 && nodes > int64(b.opts.MaxNodes) {
+		return fmt.Errorf("creating node %d of at most %d: %w", nodes, b.opts.MaxNodes, ErrNodeBudget)
+	}
+	if memory := atomic.AddInt64(&b.memory, s.estimateSize()); b.opts.MaxMemory > 0 && memory > b.opts.MaxMemory {
+		return fmt.Errorf("using an estimated %d bytes of at most %d: %w", memory, b.opts.MaxMemory, ErrMemoryBudget)
+	}
+
+	return nil
+}
+
+// buildChildren builds a child of parent for each subset, in the order the parent's
+// best gain attribute declares its values. Subtrees are handed to other goroutines
+// while worker tokens are free and otherwise built on the calling goroutine.
+func (b *builder) buildChildren(subsets []Sample, parent *Node) ([]Node, error) {
+	values := parent.Sample.BestGainAttribute.Values
+	branches := parent.Sample.branches()
+	children := make([]Node, len(subsets))
+	errs := make([]error, len(subsets))
+	buildChild := func(i int) {
+		children[i], errs[i] = b.build(subsets[i], branches[i].value, parent)
+		children[i].FilterOp, children[i].FilterValues = branches[i].op, branches[i].values
+	}
+	var wg sync.WaitGroup
+	// stop starting subtrees once one built on this goroutine fails
+	var failed bool
+	for i := 0; i < len(subsets) && !failed; i++ {
+		fmt.Fprintln(Output, "\texamining value", values[i].Value, "of", parent.Label)
+		select {
+		case b.workers <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				buildChild(i)
+				<-b.workers
+			}(i)
+		default:
+			buildChild(i)
+			failed = errs[i] != nil
+		}
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return children, nil
+}
+
+// estimateSize approximates the bytes a node for the sample holds: its row indexes,
+// the statistics of each remaining attribute and a fixed allowance for the node.
+func (s Sample) estimateSize() int64 {
+	const (
+		wordSize       = 8
+		nodeSize       = 256
+		attributeSize  = 64
+		valueStatsSize = 48
+	)
+	size := int64(nodeSize + wordSize*(len(s.rows)+len(s.attributes)))
+	for _, at := range s.AttributeTypes {
+		size += int64(attributeSize + valueStatsSize*len(at.Values))
+	}
+
+	return size
+}
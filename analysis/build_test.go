@@ -0,0 +1,279 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sameTree compares the structure, labels and counts of two trees.
+func sameTree(a, b Node) bool {
+	if a.FilterValue != b.FilterValue || a.Label != b.Label || a.Terminal != b.Terminal ||
+		!reflect.DeepEqual(a.TargetCounts, b.TargetCounts) || len(a.Children) != len(b.Children) {
+		return false
+	}
+	for i := range a.Children {
+		if !sameTree(a.Children[i], b.Children[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func quietly(t *testing.T) {
+	Output = ioutil.Discard
+	t.Cleanup(func() { Output = os.Stdout })
+}
+
+func TestBuildTreeContext_Workers(t *testing.T) {
+	quietly(t)
+	samples := []parse.Sample{syntheticSample(5000, 8)}
+	for _, filename := range []string{"../data/contact-lenses.data.txt", "../data/fishing.data.txt"} {
+		sample, err := parse.FromFile(filename)
+		if err != nil {
+			t.Fatalf("failed parsing file %s: %v", filename, err)
+		}
+		samples = append(samples, sample)
+	}
+	for _, sample := range samples {
+		serial, err := BuildTree(sample)
+		if err != nil {
+			t.Fatalf("building serial tree: %s", err.Error())
+		}
+		for _, workers := range []int{2, 4, 16} {
+			parallel, err := BuildTreeContext(context.Background(), sample, Options{Workers: workers})
+			if err != nil {
+				t.Fatalf("building tree with %d workers: %s", workers, err.Error())
+			}
+			if !sameTree(serial, parallel) {
+				t.Errorf("tree built with %d workers differs from serial tree", workers)
+			}
+		}
+	}
+}
+
+func TestBuildTreeContext_Cancelled(t *testing.T) {
+	quietly(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := BuildTreeContext(ctx, syntheticSample(1000, 8), Options{Workers: 4})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = BuildTreeContext(ctx, syntheticSample(1000, 8), Options{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestBuildTreeContext_Budgets(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	count := tree.Root().CountNodes()
+	if _, err = BuildTreeContext(context.Background(), sample, Options{MaxNodes: count}); err != nil {
+		t.Errorf("expected tree of %d nodes to fit its budget, got %s", count, err.Error())
+	}
+	_, err = BuildTreeContext(context.Background(), sample, Options{MaxNodes: count - 1, Workers: 3})
+	if !errors.Is(err, ErrNodeBudget) {
+		t.Errorf("expected ErrNodeBudget, got %v", err)
+	}
+	_, err = BuildTreeContext(context.Background(), sample, Options{MaxMemory: 1024})
+	if !errors.Is(err, ErrMemoryBudget) {
+		t.Errorf("expected ErrMemoryBudget, got %v", err)
+	}
+}
+
+func TestBuildTreeContext_MaxDepth(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	stump, err := BuildTreeContext(context.Background(), sample, Options{MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("building stump: %s", err.Error())
+	}
+	if stump.Label != "astigmatism" || len(stump.Children) != 2 {
+		t.Fatalf("expected a split on astigmatism, got %s", stump.Label)
+	}
+	// most patients need no lenses with or without astigmatism
+	for _, leaf := range stump.Children {
+		if !leaf.Terminal || leaf.Label != "none" || leaf.TargetCounts["none"] < 7 {
+			t.Errorf("expected a leaf labelled none, got %s with %v", leaf.Label, leaf.TargetCounts)
+		}
+	}
+	var deepest func(n Node) int
+	deepest = func(n Node) int {
+		var depth int
+		for _, child := range n.Children {
+			if d := deepest(child) + 1; d > depth {
+				depth = d
+			}
+		}
+		return depth
+	}
+	tree, err := BuildTreeContext(context.Background(), sample, Options{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	if d := deepest(tree); d != 2 {
+		t.Errorf("expected a tree two levels deep, got %d", d)
+	}
+	if _, err = BuildTreeContext(context.Background(), sample, Options{MaxDepth: -1}); err == nil {
+		t.Error("expected an error for a negative depth")
+	}
+}
+
+func TestBuildTreeContext_ClassWeights(t *testing.T) {
+	quietly(t)
+	sample := parse.Sample{
+		NumTargets:     2,
+		Targets:        parse.Targets{"yes", "no"},
+		NumAttributes:  1,
+		AttributeTypes: parse.AttributeTypes{{Name: "a", NumValues: 1, Values: []string{"x"}}},
+		Examples: parse.Examples{
+			{StringValues: []string{"x"}, Target: "yes"},
+			{StringValues: []string{"x"}, Target: "no"},
+			{StringValues: []string{"x"}, Target: "no"},
+			{StringValues: []string{"x"}, Target: "no"},
+		},
+	}
+	dataset, err := NewDataset(sample)
+	if err != nil {
+		t.Fatalf("encoding dataset: %s", err.Error())
+	}
+	balanced := dataset.BalancedClassWeights()
+	if balanced["yes"] != 2 || balanced["no"] != 2.0/3 {
+		t.Errorf("expected balanced weights 2 and 2/3, got %v", balanced)
+	}
+	for _, opts := range []Options{
+		{},
+		{ClassWeights: map[string]float64{"yes": 4}},
+		{BalancedClassWeights: true},
+	} {
+		tree, err := dataset.BuildTree(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("building tree: %s", err.Error())
+		}
+		expected := "yes"
+		if opts.ClassWeights == nil && !opts.BalancedClassWeights {
+			expected = "no"
+		}
+		// balanced weights tie, which goes to the first declared target
+		if label := tree.Children[0].Label; label != expected {
+			t.Errorf("with %+v expected leaf %s, got %s", opts, expected, label)
+		}
+	}
+	for _, weights := range []map[string]float64{{"yes": 0}, {"maybe": 2}} {
+		if _, err = dataset.BuildTree(context.Background(), Options{ClassWeights: weights}); err == nil {
+			t.Errorf("expected error for class weights %v", weights)
+		}
+	}
+	if dataset.weights[0] != 1 {
+		t.Error("class weights must not change the dataset's own weights")
+	}
+}
+
+func TestBuildTreeContext_BinarySplits(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	tree, err := BuildTreeContext(context.Background(), sample, Options{BinarySplits: true})
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	if tree.Label != "Forecast" || len(tree.Children) != 2 {
+		t.Fatalf("expected a binary split on Forecast, got %s with %d children", tree.Label, len(tree.Children))
+	}
+	first, second := tree.Children[0], tree.Children[1]
+	if first.FilterOp != "in" || !reflect.DeepEqual(first.FilterValues, []string{"Sunny", "Cloudy"}) ||
+		second.FilterOp != "in" || !reflect.DeepEqual(second.FilterValues, []string{"Rainy"}) {
+		t.Errorf("unexpected value sets %s %v and %s %v",
+			first.FilterOp, first.FilterValues, second.FilterOp, second.FilterValues)
+	}
+	for i, eg := range sample.Examples {
+		label, err := tree.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example %d: %s", i, err.Error())
+		}
+		if label != eg.Target {
+			t.Errorf("example %d: expected %s, got %s", i, eg.Target, label)
+		}
+	}
+}
+
+// manyValuedSample has an attribute with the given number of values, where the
+// target of each value cycles through the given targets.
+func manyValuedSample(values int, targets parse.Targets) parse.Sample {
+	at := parse.AttributeType{Name: "code", NumValues: values}
+	for i := 0; i < values; i++ {
+		at.Values = append(at.Values, fmt.Sprintf("v%d", i))
+	}
+	sample := parse.Sample{
+		NumTargets:     len(targets),
+		Targets:        targets,
+		NumAttributes:  1,
+		AttributeTypes: parse.AttributeTypes{at},
+	}
+	for i, v := range at.Values {
+		for j := 0; j < 3; j++ {
+			sample.Examples = append(sample.Examples, parse.Example{
+				StringValues: []string{v},
+				Target:       targets[i%len(targets)],
+			})
+		}
+	}
+	sample.NumExamples = len(sample.Examples)
+
+	return sample
+}
+
+func TestBuildTreeContext_BinarySplitsManyValues(t *testing.T) {
+	quietly(t)
+	// too many values to try every partition, but two targets can be ordered
+	sample := manyValuedSample(maxExactValues+4, parse.Targets{"odd", "even"})
+	tree, err := BuildTreeContext(context.Background(), sample, Options{BinarySplits: true})
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	if len(tree.Children) != 2 || len(tree.Children[0].Children) != 0 || len(tree.Children[1].Children) != 0 {
+		t.Fatalf("expected one binary split separating the targets, got %d children", len(tree.Children))
+	}
+	for _, child := range tree.Children {
+		for _, v := range child.FilterValues {
+			var i int
+			fmt.Sscanf(v, "v%d", &i)
+			if sample.Targets[i%2] != child.Label {
+				t.Errorf("value %s of target %s is in the set labelled %s", v, sample.Targets[i%2], child.Label)
+			}
+		}
+	}
+	// with more targets the values keep one branch each
+	sample = manyValuedSample(maxExactValues+4, parse.Targets{"a", "b", "c"})
+	tree, err = BuildTreeContext(context.Background(), sample, Options{BinarySplits: true})
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	if len(tree.Children) != maxExactValues+4 {
+		t.Errorf("expected a branch per value, got %d children", len(tree.Children))
+	}
+}
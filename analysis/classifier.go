@@ -0,0 +1,246 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"sort"
+	"strings"
+)
+
+// ErrUnseenValue is returned when a classifier using UnseenError meets a value that
+// no training example reaching the node had.
+var ErrUnseenValue = errors.New("value not seen in training")
+
+// UnknownLabel is the label of a prediction that failed with ErrUnseenValue.
+const UnknownLabel = "unknown"
+
+// UnseenStrategy decides how a Classifier continues at a node when the value of the
+// node's attribute is missing, has no branch, or has a branch that no training
+// example reached.
+type UnseenStrategy int
+
+const (
+	// UnseenError stops with UnknownLabel and ErrUnseenValue.
+	UnseenError UnseenStrategy = iota
+	// UnseenParentMajority predicts from the training examples that reached the node.
+	UnseenParentMajority
+	// UnseenDistribute follows every branch training examples took, weighing each by
+	// its share of them, and predicts from the combined target distributions.
+	UnseenDistribute
+	// UnseenMostFrequentBranch follows the branch most training examples took, or
+	// predicts as UnseenParentMajority does at a node without branches.
+	UnseenMostFrequentBranch
+)
+
+var unseenStrategyNames = []string{"error", "parent", "distribute", "frequent"}
+
+func (s UnseenStrategy) String() string {
+	if s < 0 || int(s) >= len(unseenStrategyNames) {
+		return fmt.Sprintf("UnseenStrategy(%d)", int(s))
+	}
+
+	return unseenStrategyNames[s]
+}
+
+// ParseUnseenStrategy returns the strategy with the given name: error, parent,
+// distribute or frequent.
+func ParseUnseenStrategy(name string) (UnseenStrategy, error) {
+	for i, n := range unseenStrategyNames {
+		if n == name {
+			return UnseenStrategy(i), nil
+		}
+	}
+
+	return UnseenError, fmt.Errorf("unknown unseen value strategy %s, expected one of %s",
+		name, strings.Join(unseenStrategyNames, ", "))
+}
+
+// Classifier predicts with a classification tree, handling values the tree was not
+// trained on as its Unseen strategy says.
+type Classifier struct {
+	Tree   Node
+	Unseen UnseenStrategy
+	// Costs, when given, chooses the target of least expected cost wherever a
+	// prediction is made from target counts rather than a leaf's label.
+	Costs *CostMatrix
+}
+
+// Step is one branch followed while classifying.
+type Step struct {
+	// Attribute is the attribute the node splits on
+	Attribute string
+	// Value is the classified value of the attribute, empty when it is missing
+	Value string
+	// Branch describes the branch followed, such as "Sunny", "<= Moderate" or
+	// "in Warm, Cold", and is empty when no branch was followed
+	Branch string `json:",omitempty"`
+	// Unseen names the strategy applied when the value was not seen in training
+	Unseen string `json:",omitempty"`
+	// Weight is the share of the prediction that followed the branch
+	Weight float64
+}
+
+// Prediction is the result of classifying one set of values.
+type Prediction struct {
+	Label string
+	// TargetCounts are the training counts the label was chosen from, combined in
+	// proportion to their weights when several leaves were reached
+	TargetCounts map[string]float64
+	// Path lists the branches followed, depth first when several were followed
+	Path []Step
+}
+
+// Classify returns the predicted label for values keyed by attribute name.
+func (c Classifier) Classify(values map[string]string) (string, error) {
+	p, err := c.Predict(values)
+	return p.Label, err
+}
+
+// ClassifyExample classifies a parsed example whose values are laid out according
+// to the given attribute types.
+func (c Classifier) ClassifyExample(attributeTypes parse.AttributeTypes, eg parse.Example) (string, error) {
+	values, err := attributeTypes.ExampleValues(eg)
+	if err != nil {
+		return "", fmt.Errorf("classifying example: %w", err)
+	}
+
+	return c.Classify(values)
+}
+
+// Predict classifies values keyed by attribute name, reporting the path taken.
+// Reaching a single leaf predicts its label, as Node.Classify does.
+func (c Classifier) Predict(values map[string]string) (Prediction, error) {
+	var p Prediction
+	counts := make(map[string]float64)
+	var leaves []Node
+	if err := c.predict(c.Tree, values, 1, counts, &leaves, &p.Path); err != nil {
+		p.Label = UnknownLabel
+		return p, err
+	}
+	p.TargetCounts = counts
+	if len(leaves) == 1 && leaves[0].Terminal {
+		p.Label = leaves[0].Label
+		return p, nil
+	}
+	p.Label = c.bestTarget(counts)
+
+	return p, nil
+}
+
+// predict adds the target distribution reached from node, scaled by weight, to
+// counts, appending the leaves or stopping nodes reached and the steps taken.
+func (c Classifier) predict(node Node, values map[string]string, weight float64,
+	counts map[string]float64, leaves *[]Node, path *[]Step) error {
+	if node.Terminal {
+		addScaled(counts, node.TargetCounts, weight)
+		*leaves = append(*leaves, node)
+		return nil
+	}
+	value, ok := values[node.Label]
+	if ok {
+		for _, child := range node.Children {
+			if child.Admits(value) && total(child.TargetCounts) > 0 {
+				*path = append(*path, Step{Attribute: node.Label, Value: value, Branch: child.filter(), Weight: weight})
+				return c.predict(child, values, weight, counts, leaves, path)
+			}
+		}
+	}
+	step := Step{Attribute: node.Label, Value: value, Unseen: c.Unseen.String(), Weight: weight}
+	switch c.Unseen {
+	case UnseenMostFrequentBranch:
+		var best *Node
+		for i := range node.Children {
+			if best == nil || total(node.Children[i].TargetCounts) > total(best.TargetCounts) {
+				best = &node.Children[i]
+			}
+		}
+		if best != nil {
+			step.Branch = best.filter()
+			*path = append(*path, step)
+			return c.predict(*best, values, weight, counts, leaves, path)
+		}
+		// a node without branches, as an imported tree may have, predicts from its
+		// own counts
+		fallthrough
+	case UnseenParentMajority:
+		*path = append(*path, step)
+		addScaled(counts, node.TargetCounts, weight)
+		*leaves = append(*leaves, node)
+		return nil
+	case UnseenDistribute:
+		nodeTotal := total(node.TargetCounts)
+		for _, child := range node.Children {
+			childTotal := total(child.TargetCounts)
+			if childTotal == 0 {
+				continue
+			}
+			step.Branch, step.Weight = child.filter(), weight*childTotal/nodeTotal
+			*path = append(*path, step)
+			if err := c.predict(child, values, step.Weight, counts, leaves, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		*path = append(*path, step)
+		if !ok {
+			return fmt.Errorf("no value given for attribute %s: %w", node.Label, ErrUnseenValue)
+		}
+		return fmt.Errorf("no branch for value %s of attribute %s: %w", value, node.Label, ErrUnseenValue)
+	}
+}
+
+// bestTarget returns the target of least expected cost when the classifier has
+// costs, and the most common target otherwise, preferring the target the tree
+// was trained with first on ties.
+func (c Classifier) bestTarget(counts map[string]float64) string {
+	if c.Costs != nil {
+		return c.Costs.MinCostTarget(counts)
+	}
+
+	return NewCostMatrix(c.Tree.targets()).MinCostTarget(counts)
+}
+
+// targets returns the targets the tree was trained with, or the targets counted
+// at the node in name order for a tree that was not built here.
+func (n Node) targets() parse.Targets {
+	if n.Sample.dataset != nil {
+		return n.Sample.dataset.Targets
+	}
+	var targets parse.Targets
+	for t := range n.TargetCounts {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+
+	return targets
+}
+
+// filter describes which of its parent's examples the node holds.
+func (n Node) filter() string {
+	if n.FilterOp == "" {
+		return n.FilterValue
+	}
+
+	return n.FilterOp + " " + n.FilterValue
+}
+
+func addScaled(counts, add map[string]float64, weight float64) {
+	addTotal := total(add)
+	if addTotal == 0 {
+		return
+	}
+	for t, count := range add {
+		counts[t] += weight * count / addTotal
+	}
+}
+
+func total(counts map[string]float64) float64 {
+	var sum float64
+	for _, count := range counts {
+		sum += count
+	}
+
+	return sum
+}
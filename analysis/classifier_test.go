@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"errors"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"testing"
+)
+
+func TestClassifier_Unseen(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree failed: %s", err.Error())
+	}
+	for i, eg := range sample.Examples {
+		label, err := Classifier{Tree: tree}.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil || label != eg.Target {
+			t.Errorf("example %d: expected %s, got %s with %v", i, eg.Target, label, err)
+		}
+	}
+
+	snowy := map[string]string{"Forecast": "Snowy", "Wind": "Weak", "Water": "Warm", "Air": "Cool"}
+	p, err := Classifier{Tree: tree}.Predict(snowy)
+	if !errors.Is(err, ErrUnseenValue) || p.Label != UnknownLabel {
+		t.Errorf("expected %s with ErrUnseenValue, got %s with %v", UnknownLabel, p.Label, err)
+	}
+	if len(p.Path) != 1 || p.Path[0].Unseen != "error" {
+		t.Errorf("expected the path to report the unseen forecast, got %+v", p.Path)
+	}
+
+	cases := []struct {
+		unseen UnseenStrategy
+		label  string
+		steps  int
+	}{
+		// nine of the fourteen days were good for fishing
+		{UnseenParentMajority, "Yes", 1},
+		// Sunny is the most common forecast, and a weak wind and warm water mean no
+		{UnseenMostFrequentBranch, "No", 3},
+		// sunny and rainy days lead to No, outweighing the few cloudy days
+		{UnseenDistribute, "No", 6},
+	}
+	for _, c := range cases {
+		p, err := Classifier{Tree: tree, Unseen: c.unseen}.Predict(snowy)
+		if err != nil {
+			t.Fatalf("%s: %s", c.unseen, err.Error())
+		}
+		if p.Label != c.label || len(p.Path) != c.steps {
+			t.Errorf("%s: expected %s in %d steps, got %s in %+v", c.unseen, c.label, c.steps, p.Label, p.Path)
+		}
+		if p.Path[0].Unseen != c.unseen.String() {
+			t.Errorf("%s: expected the first step to report the strategy, got %+v", c.unseen, p.Path[0])
+		}
+		var weight float64
+		for _, count := range p.TargetCounts {
+			weight += count
+		}
+		if weight < 0.999 || weight > 1.001 {
+			t.Errorf("%s: expected target counts to sum to one, got %v", c.unseen, p.TargetCounts)
+		}
+	}
+
+	// a missing value is unseen too
+	if _, err = (Classifier{Tree: tree}).Classify(map[string]string{}); !errors.Is(err, ErrUnseenValue) {
+		t.Errorf("expected ErrUnseenValue for a missing value, got %v", err)
+	}
+	if _, err = ParseUnseenStrategy("guess"); err == nil {
+		t.Error("expected an error parsing an unknown strategy")
+	}
+}
+
+func TestClassifier_NoBranches(t *testing.T) {
+	// an imported tree can have a node that splits without any branches
+	tree := Node{Label: "Forecast", TargetCounts: map[string]float64{"Yes": 2, "No": 1}}
+	for _, unseen := range []UnseenStrategy{UnseenParentMajority, UnseenMostFrequentBranch} {
+		p, err := Classifier{Tree: tree, Unseen: unseen}.Predict(map[string]string{"Forecast": "Sunny"})
+		if err != nil || p.Label != "Yes" || len(p.Path) != 1 {
+			t.Errorf("%s: expected Yes from the node's own counts, got %s in %+v with %v", unseen, p.Label, p.Path, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+)
+
+// Classify walks the tree from this node using the given attribute values, keyed
+// by attribute name, and returns the label of the terminal node that is reached.
+func (n Node) Classify(values map[string]string) (string, error) {
+	path, err := n.path(values)
+	if err != nil {
+		return "", err
+	}
+
+	return path[len(path)-1].Label, nil
+}
+
+// ClassifyWithCosts classifies as Classify does, but predicts the target with the
+// least expected cost given the training examples that reached the terminal node,
+// or its nearest ancestor that training examples reached, whatever the node's label.
+func (n Node) ClassifyWithCosts(values map[string]string, costs *CostMatrix) (string, error) {
+	path, err := n.path(values)
+	if err != nil {
+		return "", err
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		for _, count := range path[i].TargetCounts {
+			if count > 0 {
+				return costs.MinCostTarget(path[i].TargetCounts), nil
+			}
+		}
+	}
+
+	return path[len(path)-1].Label, nil
+}
+
+// ClassifyExample classifies a parsed example whose values are laid out according
+// to the given attribute types.
+func (n Node) ClassifyExample(attributeTypes parse.AttributeTypes, eg parse.Example) (string, error) {
+	values, err := attributeTypes.ExampleValues(eg)
+	if err != nil {
+		return "", fmt.Errorf("classifying example: %w", err)
+	}
+
+	return n.Classify(values)
+}
+
+// ClassifyExampleWithCosts classifies a parsed example as ClassifyWithCosts does.
+func (n Node) ClassifyExampleWithCosts(attributeTypes parse.AttributeTypes, eg parse.Example, costs *CostMatrix) (string, error) {
+	values, err := attributeTypes.ExampleValues(eg)
+	if err != nil {
+		return "", fmt.Errorf("classifying example: %w", err)
+	}
+
+	return n.ClassifyWithCosts(values, costs)
+}
+
+// path returns the nodes from this node to the terminal node reached by the values.
+func (n Node) path(values map[string]string) ([]Node, error) {
+	path := []Node{n}
+	for node := n; !node.Terminal; {
+		value, ok := values[node.Label]
+		if !ok {
+			return nil, fmt.Errorf("no value given for attribute %s", node.Label)
+		}
+		child, ok := node.child(value)
+		if !ok {
+			return nil, fmt.Errorf("no branch for value %s of attribute %s", value, node.Label)
+		}
+		node = child
+		path = append(path, node)
+	}
+
+	return path, nil
+}
+
+func (n Node) child(value string) (Node, bool) {
+	for _, c := range n.Children {
+		if c.Admits(value) {
+			return c, true
+		}
+	}
+
+	return Node{}, false
+}
+
+// Admits reports whether the node holds the examples having the value for its
+// parent's split attribute.
+func (n Node) Admits(value string) bool {
+	if n.FilterOp == "" {
+		return n.FilterValue == value
+	}
+	for _, v := range n.FilterValues {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
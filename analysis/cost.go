@@ -0,0 +1,172 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+	"math"
+	"os"
+)
+
+// CostMatrix gives the cost of predicting a target for an example of another. Leaves
+// labelled with a cost matrix predict the target with the least expected cost given
+// the training examples that reached them, rather than the most common target.
+type CostMatrix struct {
+	Targets parse.Targets `json:"targets"`
+	// Costs is indexed by the actual target and then the predicted target
+	Costs [][]float64 `json:"costs"`
+}
+
+// NewCostMatrix returns a matrix where every misclassification costs one and every
+// correct prediction costs nothing, under which the least cost target is the most
+// common one.
+func NewCostMatrix(targets parse.Targets) *CostMatrix {
+	m := &CostMatrix{
+		Targets: targets,
+		Costs:   make([][]float64, len(targets)),
+	}
+	for i := range m.Costs {
+		m.Costs[i] = make([]float64, len(targets))
+		for j := range m.Costs[i] {
+			if i != j {
+				m.Costs[i][j] = 1
+			}
+		}
+	}
+
+	return m
+}
+
+// CostMatrixFromFile reads a cost matrix from a JSON file.
+func CostMatrixFromFile(filename string) (*CostMatrix, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	defer file.Close()
+	m, err := ReadCostMatrix(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+
+	return m, nil
+}
+
+// ReadCostMatrix decodes and validates a JSON cost matrix, such as
+// {"targets": ["pos", "neg"], "costs": [[0, 5], [1, 0]]} where predicting neg for a
+// pos example costs 5.
+func ReadCostMatrix(r io.Reader) (*CostMatrix, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var m CostMatrix
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("decoding cost matrix: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Set changes the cost of predicting a target for an example of the actual target.
+func (m *CostMatrix) Set(actual, predicted string, cost float64) error {
+	iActual, err := m.Targets.Index(actual)
+	if err != nil {
+		return fmt.Errorf("setting cost of actual target: %w", err)
+	}
+	iPredicted, err := m.Targets.Index(predicted)
+	if err != nil {
+		return fmt.Errorf("setting cost of predicted target: %w", err)
+	}
+	m.Costs[iActual][iPredicted] = cost
+
+	return nil
+}
+
+// Cost returns the cost of predicting a target for an example of the actual target.
+func (m *CostMatrix) Cost(actual, predicted string) (float64, error) {
+	iActual, err := m.Targets.Index(actual)
+	if err != nil {
+		return 0, fmt.Errorf("finding cost of actual target: %w", err)
+	}
+	iPredicted, err := m.Targets.Index(predicted)
+	if err != nil {
+		return 0, fmt.Errorf("finding cost of predicted target: %w", err)
+	}
+
+	return m.Costs[iActual][iPredicted], nil
+}
+
+// Validate checks that the matrix has a row and column for each distinct target and
+// that every cost is finite and not negative.
+func (m *CostMatrix) Validate() error {
+	if len(m.Targets) == 0 {
+		return fmt.Errorf("cost matrix has no targets")
+	}
+	seen := make(map[string]bool)
+	for _, t := range m.Targets {
+		if seen[t] {
+			return fmt.Errorf("cost matrix target %s is repeated", t)
+		}
+		seen[t] = true
+	}
+	if len(m.Costs) != len(m.Targets) {
+		return fmt.Errorf("cost matrix has %d rows for %d targets", len(m.Costs), len(m.Targets))
+	}
+	for i, row := range m.Costs {
+		if len(row) != len(m.Targets) {
+			return fmt.Errorf("cost matrix row for %s has %d costs for %d targets",
+				m.Targets[i], len(row), len(m.Targets))
+		}
+		for j, cost := range row {
+			if cost < 0 || math.IsNaN(cost) || math.IsInf(cost, 0) {
+				return fmt.Errorf("cost %v of predicting %s for %s is not a finite, non-negative number",
+					cost, m.Targets[j], m.Targets[i])
+			}
+		}
+	}
+
+	return nil
+}
+
+// covers returns an error naming the first target the matrix has no costs for.
+func (m *CostMatrix) covers(targets parse.Targets) error {
+	for _, t := range targets {
+		if !m.Targets.IsValid(t) {
+			return fmt.Errorf("cost matrix has no costs for target %s", t)
+		}
+	}
+
+	return nil
+}
+
+// ExpectedCost returns the total cost of predicting a target for examples with the
+// given weight of each target.
+func (m *CostMatrix) ExpectedCost(targetCounts map[string]float64, predicted string) float64 {
+	iPredicted, err := m.Targets.Index(predicted)
+	if err != nil {
+		return math.Inf(1)
+	}
+	var cost float64
+	for i, actual := range m.Targets {
+		cost += targetCounts[actual] * m.Costs[i][iPredicted]
+	}
+
+	return cost
+}
+
+// MinCostTarget returns the target with the least expected cost for examples with
+// the given weight of each target, preferring the target listed first on ties.
+func (m *CostMatrix) MinCostTarget(targetCounts map[string]float64) string {
+	var lowestName string
+	lowestCost := math.Inf(1)
+	for _, predicted := range m.Targets {
+		if cost := m.ExpectedCost(targetCounts, predicted); cost < lowestCost {
+			lowestName, lowestCost = predicted, cost
+		}
+	}
+
+	return lowestName
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"context"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"strings"
+	"testing"
+)
+
+func TestReadCostMatrix(t *testing.T) {
+	m, err := ReadCostMatrix(strings.NewReader(`{"targets": ["pos", "neg"], "costs": [[0, 5], [1, 0]]}`))
+	if err != nil {
+		t.Fatalf("reading cost matrix: %s", err.Error())
+	}
+	if cost, _ := m.Cost("pos", "neg"); cost != 5 {
+		t.Errorf("expected predicting neg for pos to cost 5, got %v", cost)
+	}
+	counts := map[string]float64{"pos": 1, "neg": 3}
+	if m.ExpectedCost(counts, "neg") != 5 || m.ExpectedCost(counts, "pos") != 3 {
+		t.Errorf("unexpected expected costs %v and %v", m.ExpectedCost(counts, "neg"), m.ExpectedCost(counts, "pos"))
+	}
+	if target := m.MinCostTarget(counts); target != "pos" {
+		t.Errorf("expected least cost target pos, got %s", target)
+	}
+	if target := NewCostMatrix(m.Targets).MinCostTarget(counts); target != "neg" {
+		t.Errorf("expected zero-one costs to choose the most common target neg, got %s", target)
+	}
+	for _, invalid := range []string{
+		`{"targets": ["pos", "neg"], "costs": [[0, 5]]}`,
+		`{"targets": ["pos", "neg"], "costs": [[0, 5], [1]]}`,
+		`{"targets": ["pos", "neg"], "costs": [[0, -5], [1, 0]]}`,
+		`{"targets": ["pos", "pos"], "costs": [[0, 5], [1, 0]]}`,
+		`{"targets": ["pos", "neg"], "costs": [[0, 5], [1, 0]], "extra": 1}`,
+	} {
+		if _, err = ReadCostMatrix(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestBuildTreeContext_Costs(t *testing.T) {
+	quietly(t)
+	sample := parse.Sample{
+		NumTargets:     2,
+		Targets:        parse.Targets{"yes", "no"},
+		NumAttributes:  1,
+		AttributeTypes: parse.AttributeTypes{{Name: "a", NumValues: 2, Values: []string{"x", "y"}}},
+		Examples: parse.Examples{
+			{StringValues: []string{"x"}, Target: "yes"},
+			{StringValues: []string{"x"}, Target: "no"},
+			{StringValues: []string{"x"}, Target: "no"},
+			{StringValues: []string{"x"}, Target: "no"},
+		},
+	}
+	costs := NewCostMatrix(sample.Targets)
+	costs.Set("yes", "no", 5)
+	tree, err := BuildTreeContext(context.Background(), sample, Options{})
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	if label := tree.Children[0].Label; label != "no" {
+		t.Errorf("expected the most common target no, got %s", label)
+	}
+	values := map[string]string{"a": "x"}
+	if label, _ := tree.ClassifyWithCosts(values, costs); label != "yes" {
+		t.Errorf("expected costs at prediction time to choose yes, got %s", label)
+	}
+	// the unseen value y reaches a leaf without examples, so the root's counts decide
+	if label, _ := tree.ClassifyWithCosts(map[string]string{"a": "y"}, costs); label != "yes" {
+		t.Errorf("expected costs at prediction time to choose yes for an empty leaf, got %s", label)
+	}
+	tree, err = BuildTreeContext(context.Background(), sample, Options{Costs: costs})
+	if err != nil {
+		t.Fatalf("building tree with costs: %s", err.Error())
+	}
+	for _, child := range tree.Children {
+		if child.Label != "yes" {
+			t.Errorf("expected the least cost target yes for value %s, got %s", child.FilterValue, child.Label)
+		}
+	}
+	_, err = BuildTreeContext(context.Background(), sample, Options{Costs: NewCostMatrix(parse.Targets{"yes"})})
+	if err == nil {
+		t.Error("expected error for a cost matrix missing a target")
+	}
+}
+
+func TestNode_Prune(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	nodes := tree.Root().CountNodes()
+	removed, err := tree.Prune(sample, nil)
+	if err != nil {
+		t.Fatalf("pruning with the training sample: %s", err.Error())
+	}
+	if removed != 0 {
+		t.Errorf("expected a tree that fits its training sample to keep every node, removed %d", removed)
+	}
+	// with nothing to validate against, a leaf is never worse than a subtree
+	empty := sample
+	empty.Examples = nil
+	removed, err = tree.Prune(empty, nil)
+	if err != nil {
+		t.Fatalf("pruning with an empty sample: %s", err.Error())
+	}
+	if removed != nodes-1 || !tree.Terminal || tree.Label != tree.mostCommonTarget() {
+		t.Errorf("expected the tree to become a leaf of %s removing %d nodes, got %s removing %d",
+			tree.mostCommonTarget(), nodes-1, tree.Label, removed)
+	}
+}
@@ -0,0 +1,263 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+)
+
+// Dataset is a columnar encoding of a parse.Sample that every node of a tree
+// shares. Each nominal attribute is stored as a column of value codes, which are
+// indexes into the attribute type's Values, and each example's target as an index
+// into Targets, so that a node only needs the row indexes of its examples. A
+// dataset with a RealTarget holds each example's target value instead.
+type Dataset struct {
+	Targets        parse.Targets
+	AttributeTypes parse.AttributeTypes
+	// RealTarget names the real-valued target of a regression dataset
+	RealTarget string
+	// columns holds one code per row for each nominal attribute and is nil for real attributes
+	columns [][]int
+	// targets holds the target code of each row and is nil for a regression dataset
+	targets []int
+	// values holds the target value of each row of a regression dataset
+	values []float64
+	// weights holds the effective weight of each row
+	weights []float64
+}
+
+// NewDataset encodes the sample, validating every value and target against the
+// sample's attribute types and targets.
+func NewDataset(sample parse.Sample) (*Dataset, error) {
+	enc := newEncoder(sample, len(sample.Examples))
+	for row, eg := range sample.Examples {
+		if err := enc.add(eg); err != nil {
+			return nil, fmt.Errorf("encoding example %d: %w", row, err)
+		}
+	}
+
+	return enc.dataset, nil
+}
+
+// maxReservedRows limits the rows ReadDataset reserves up front from a header's
+// example count; columns grow past it as examples arrive.
+const maxReservedRows = 1 << 16
+
+// ReadDataset encodes every example from the reader as it is parsed, so that the
+// examples of a parse.Sample never need to be held in memory together.
+func ReadDataset(r *parse.Reader) (*Dataset, error) {
+	header := r.Header()
+	// the header's count is only a hint, since a file may claim more examples than it has
+	rows := header.NumExamples
+	if rows < 0 || rows > maxReservedRows {
+		rows = maxReservedRows
+	}
+	enc := newEncoder(header, rows)
+	for row := 0; ; row++ {
+		eg, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading example %d: %w", row, err)
+		}
+		if err = enc.add(eg); err != nil {
+			return nil, fmt.Errorf("encoding example %d: %w", row, err)
+		}
+	}
+
+	return enc.dataset, nil
+}
+
+// encoder appends examples to a dataset, translating values into codes
+type encoder struct {
+	dataset     *Dataset
+	targetCodes map[string]int
+	valueCodes  []map[string]int
+	// stringIndexes maps each nominal attribute to its position in Example.StringValues
+	stringIndexes []int
+	numStrings    int
+}
+
+// newEncoder prepares an empty dataset for the header's targets and attribute types
+// with room for the given number of rows.
+func newEncoder(header parse.Sample, rows int) *encoder {
+	enc := &encoder{
+		dataset: &Dataset{
+			Targets:        header.Targets,
+			AttributeTypes: header.AttributeTypes,
+			RealTarget:     header.RealTarget,
+			columns:        make([][]int, len(header.AttributeTypes)),
+			weights:        make([]float64, 0, rows),
+		},
+		targetCodes:   make(map[string]int, len(header.Targets)),
+		valueCodes:    make([]map[string]int, len(header.AttributeTypes)),
+		stringIndexes: make([]int, len(header.AttributeTypes)),
+	}
+	if header.RealTarget != "" {
+		enc.dataset.values = make([]float64, 0, rows)
+	} else {
+		enc.dataset.targets = make([]int, 0, rows)
+	}
+	for i, t := range header.Targets {
+		enc.targetCodes[t] = i
+	}
+	for i, at := range header.AttributeTypes {
+		if at.Real {
+			continue
+		}
+		enc.dataset.columns[i] = make([]int, 0, rows)
+		enc.valueCodes[i] = make(map[string]int, len(at.Values))
+		for code, v := range at.Values {
+			enc.valueCodes[i][v] = code
+		}
+		enc.stringIndexes[i] = enc.numStrings
+		enc.numStrings++
+	}
+
+	return enc
+}
+
+func (enc *encoder) add(eg parse.Example) error {
+	d := enc.dataset
+	targetCode, codes, err := enc.encode(eg)
+	if err != nil {
+		return err
+	}
+	for i, column := range d.columns {
+		if column != nil {
+			d.columns[i] = append(column, codes[i])
+		}
+	}
+	if d.regression() {
+		d.values = append(d.values, eg.Value)
+	} else {
+		d.targets = append(d.targets, targetCode)
+	}
+	d.weights = append(d.weights, eg.EffectiveWeight())
+
+	return nil
+}
+
+// encode returns the code of the example's target, which is zero for a regression
+// dataset, and the code of its value of each nominal attribute by attribute index.
+func (enc *encoder) encode(eg parse.Example) (int, []int, error) {
+	d := enc.dataset
+	targetCode, ok := enc.targetCodes[eg.Target]
+	if !ok && !d.regression() {
+		return 0, nil, fmt.Errorf("finding target: %w", parse.ErrIndexNotFound{For: eg.Target})
+	}
+	if len(eg.StringValues) != enc.numStrings {
+		return 0, nil, fmt.Errorf("example has %d nominal values, expected %d", len(eg.StringValues), enc.numStrings)
+	}
+	codes := make([]int, len(d.columns))
+	for i, column := range d.columns {
+		if column == nil {
+			continue
+		}
+		v := eg.StringValues[enc.stringIndexes[i]]
+		code, ok := enc.valueCodes[i][v]
+		if !ok {
+			return 0, nil, fmt.Errorf("finding value of %s: %w", d.AttributeTypes[i].Name, parse.ErrIndexNotFound{For: v})
+		}
+		codes[i] = code
+	}
+
+	return targetCode, codes, nil
+}
+
+// Len returns the number of rows in the dataset.
+func (d *Dataset) Len() int {
+	return len(d.weights)
+}
+
+// regression reports whether the dataset has a real target to predict rather than
+// one of Targets.
+func (d *Dataset) regression() bool {
+	return d.RealTarget != ""
+}
+
+func (d *Dataset) allRows() []int {
+	rows := make([]int, d.Len())
+	for i := range rows {
+		rows[i] = i
+	}
+
+	return rows
+}
+
+// nominalAttributes returns the indexes of attributes that can be split on.
+func (d *Dataset) nominalAttributes() []int {
+	var attributes []int
+	for i, column := range d.columns {
+		if column != nil {
+			attributes = append(attributes, i)
+		}
+	}
+
+	return attributes
+}
+
+// targetCounts returns the total weight of the given rows having each target code,
+// which a regression dataset has none of.
+func (d *Dataset) targetCounts(rows []int) []float64 {
+	counts := make([]float64, len(d.Targets))
+	if d.regression() {
+		return counts
+	}
+	for _, row := range rows {
+		counts[d.targets[row]] += d.weights[row]
+	}
+
+	return counts
+}
+
+// valueTargetCounts returns, for each value code of the attribute, the total
+// weight of the given rows having each target code.
+func (d *Dataset) valueTargetCounts(attribute int, rows []int) [][]float64 {
+	counts := make([][]float64, len(d.AttributeTypes[attribute].Values))
+	for i := range counts {
+		counts[i] = make([]float64, len(d.Targets))
+	}
+	column := d.columns[attribute]
+	for _, row := range rows {
+		counts[column[row]][d.targets[row]] += d.weights[row]
+	}
+
+	return counts
+}
+
+// partition splits the given rows into one subset per value code of the attribute.
+func (d *Dataset) partition(attribute int, rows []int) [][]int {
+	column := d.columns[attribute]
+	sizes := make([]int, len(d.AttributeTypes[attribute].Values))
+	for _, row := range rows {
+		sizes[column[row]]++
+	}
+	parts := make([][]int, len(sizes))
+	for i, size := range sizes {
+		parts[i] = make([]int, 0, size)
+	}
+	for _, row := range rows {
+		code := column[row]
+		parts[code] = append(parts[code], row)
+	}
+
+	return parts
+}
+
+// partitionBinary splits the given rows into those whose value code of the
+// attribute is marked in first and those whose code is not.
+func (d *Dataset) partitionBinary(attribute int, rows []int, first []bool) [][]int {
+	column := d.columns[attribute]
+	parts := [][]int{{}, {}}
+	for _, row := range rows {
+		if first[column[row]] {
+			parts[0] = append(parts[0], row)
+		} else {
+			parts[1] = append(parts[1], row)
+		}
+	}
+
+	return parts
+}
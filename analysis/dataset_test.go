@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewDataset(t *testing.T) {
+	sample, err := parse.FromFile("../data/new-treatment.data.txt")
+	if err != nil {
+		t.Errorf("failed parsing file new-treatment.data.txt: %v", err)
+	}
+	d, err := NewDataset(sample)
+	if err != nil {
+		t.Fatalf("building dataset: %s", err.Error())
+	}
+	if d.Len() != 5 {
+		t.Errorf("expected 5 rows, got %d", d.Len())
+	}
+	// age is <25, 25-40, >40, >40, >40
+	if !reflect.DeepEqual(d.columns[2], []int{0, 1, 2, 2, 2}) {
+		t.Errorf("unexpected age codes %v", d.columns[2])
+	}
+	if !reflect.DeepEqual(d.targetCounts(d.allRows()), []float64{3, 2}) {
+		t.Errorf("unexpected target counts %v", d.targetCounts(d.allRows()))
+	}
+	parts := d.partition(1, d.allRows())
+	if !reflect.DeepEqual(parts, [][]int{{0, 1, 2}, {3, 4}}) {
+		t.Errorf("unexpected partition on bp %v", parts)
+	}
+	counts := d.valueTargetCounts(2, []int{1, 2, 3})
+	if !reflect.DeepEqual(counts, [][]float64{{0, 0}, {1, 0}, {1, 1}}) {
+		t.Errorf("unexpected age target counts %v", counts)
+	}
+
+	sample.Examples[0].StringValues[2] = "100+"
+	if _, err = NewDataset(sample); err == nil {
+		t.Error("expected error for invalid attribute value")
+	}
+}
+
+func TestReadDataset(t *testing.T) {
+	quietly(t)
+	sample := syntheticSample(20000, 6)
+	var buf bytes.Buffer
+	if err := parse.Write(&buf, sample); err != nil {
+		t.Fatalf("writing synthetic sample: %s", err.Error())
+	}
+	r, err := parse.NewReader(&buf, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("reading header: %s", err.Error())
+	}
+	streamed, err := ReadDataset(r)
+	if err != nil {
+		t.Fatalf("reading dataset: %s", err.Error())
+	}
+	encoded, err := NewDataset(sample)
+	if err != nil {
+		t.Fatalf("encoding dataset: %s", err.Error())
+	}
+	if !reflect.DeepEqual(streamed.columns, encoded.columns) || !reflect.DeepEqual(streamed.targets, encoded.targets) {
+		t.Error("streamed dataset differs from the dataset encoded from a parsed sample")
+	}
+	streamedTree, err := streamed.BuildTree(context.Background(), Options{})
+	if err != nil {
+		t.Fatalf("building tree from streamed dataset: %s", err.Error())
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	if !sameTree(streamedTree, tree) {
+		t.Error("tree built from streamed dataset differs")
+	}
+}
+
+func TestReadDataset_HugeCount(t *testing.T) {
+	r, err := parse.NewReader(strings.NewReader(`2
+yes,no
+1
+windy,2,true,false
+9999999999999999
+true,yes
+false,no
+`), parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("reading header: %s", err.Error())
+	}
+	// the file ends long before the count it claims, which is an error but not a panic
+	if _, err = ReadDataset(r); err == nil {
+		t.Error("expected an error for a file with fewer examples than its header declares")
+	}
+}
@@ -0,0 +1,268 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+)
+
+// HoeffdingOptions controls how a HoeffdingTree grows. The zero value uses the
+// defaults below.
+type HoeffdingOptions struct {
+	// Delta is the probability that a leaf splits on a different attribute than it
+	// would given every example of the stream, 1e-7 when zero.
+	Delta float64
+	// TieThreshold lets a leaf split once the Hoeffding bound falls below it even
+	// though its best two attributes are still too close to tell apart, 0.05 when zero.
+	TieThreshold float64
+	// GracePeriod is the weight of examples a leaf receives between attempts to
+	// split it, 200 when zero.
+	GracePeriod float64
+	// MaxDepth is the deepest a node may be, counting the root as depth zero, as in
+	// Options. Zero means no limit.
+	MaxDepth int
+}
+
+// HoeffdingTree grows a classification tree from a stream of examples that is
+// never held in memory, in the manner of the Very Fast Decision Tree of Domingos
+// and Hulten (2000). Each leaf counts the weight of the examples reaching it by
+// target and by value and target of each remaining nominal attribute. Every
+// GracePeriod of weight, the leaf splits on the attribute of highest information
+// gain once the Hoeffding bound shows, with probability 1-Delta, that it beats the
+// runner-up. Attributes split into one branch per value, and real attributes are
+// ignored as they are when building a tree.
+type HoeffdingTree struct {
+	encoder *encoder
+	opts    HoeffdingOptions
+	root    *hoeffdingNode
+	seen    float64
+}
+
+// hoeffdingNode is a node of a HoeffdingTree. A leaf has no children and keeps
+// valueCounts; a node that has split keeps the statistics it split with.
+type hoeffdingNode struct {
+	depth int
+	// attributes are the dataset indexes of the attributes the leaf may split on
+	attributes  []int
+	classCounts []float64
+	// valueCounts holds the weight of the leaf's examples by value code and target
+	// code of each attribute, in the order of attributes
+	valueCounts [][][]float64
+	// sinceAttempt is the weight of examples received since the leaf last tried to split
+	sinceAttempt float64
+	split        AttributeType
+	splitStats   AttributeTypes
+	children     []*hoeffdingNode
+}
+
+// NewHoeffdingTree returns an empty tree for examples with the targets and
+// attribute types of the header, such as that of a parse.Reader.
+func NewHoeffdingTree(header parse.Sample, opts HoeffdingOptions) (*HoeffdingTree, error) {
+	if header.RealTarget != "" {
+		return nil, fmt.Errorf("a Hoeffding tree needs a nominal target, not the real target %s", header.RealTarget)
+	}
+	if len(header.Targets) == 0 {
+		return nil, fmt.Errorf("a Hoeffding tree needs at least one target")
+	}
+	if opts.Delta < 0 || opts.Delta >= 1 || opts.TieThreshold < 0 || opts.GracePeriod < 0 || opts.MaxDepth < 0 {
+		return nil, fmt.Errorf("delta %v must be below one, and it, the tie threshold %v, grace period %v and maximum depth %d must not be negative",
+			opts.Delta, opts.TieThreshold, opts.GracePeriod, opts.MaxDepth)
+	}
+	if opts.Delta == 0 {
+		opts.Delta = 1e-7
+	}
+	if opts.TieThreshold == 0 {
+		opts.TieThreshold = 0.05
+	}
+	if opts.GracePeriod == 0 {
+		opts.GracePeriod = 200
+	}
+	enc := newEncoder(header, 0)
+	h := &HoeffdingTree{encoder: enc, opts: opts}
+	h.root = h.newLeaf(enc.dataset.nominalAttributes(), 0)
+
+	return h, nil
+}
+
+func (h *HoeffdingTree) newLeaf(attributes []int, depth int) *hoeffdingNode {
+	d := h.encoder.dataset
+	leaf := &hoeffdingNode{
+		depth:       depth,
+		attributes:  attributes,
+		classCounts: make([]float64, len(d.Targets)),
+		valueCounts: make([][][]float64, len(attributes)),
+	}
+	for i, attribute := range attributes {
+		leaf.valueCounts[i] = make([][]float64, len(d.AttributeTypes[attribute].Values))
+		for code := range leaf.valueCounts[i] {
+			leaf.valueCounts[i][code] = make([]float64, len(d.Targets))
+		}
+	}
+
+	return leaf
+}
+
+// Seen returns the total weight of the examples learned from.
+func (h *HoeffdingTree) Seen() float64 {
+	return h.seen
+}
+
+// Learn adds an example to the counts of the nodes on its path and tries to split
+// the leaf it reaches once that leaf has received another GracePeriod of weight.
+func (h *HoeffdingTree) Learn(eg parse.Example) error {
+	target, codes, err := h.encoder.encode(eg)
+	if err != nil {
+		return fmt.Errorf("learning example: %w", err)
+	}
+	w := eg.EffectiveWeight()
+	h.seen += w
+	node := h.root
+	for {
+		node.classCounts[target] += w
+		if node.children == nil {
+			break
+		}
+		node = node.children[codes[node.split.index]]
+	}
+	for i, attribute := range node.attributes {
+		node.valueCounts[i][codes[attribute]][target] += w
+	}
+	node.sinceAttempt += w
+	if node.sinceAttempt >= h.opts.GracePeriod {
+		node.sinceAttempt = 0
+		h.attemptSplit(node)
+	}
+
+	return nil
+}
+
+// attemptSplit splits the leaf on its attribute of highest gain when the Hoeffding
+// bound for the weight of its examples is less than the gain's lead over the next
+// best attribute, or than TieThreshold. A leaf whose examples all have one target,
+// that has no attributes left or that is at MaxDepth does not split.
+func (h *HoeffdingTree) attemptSplit(leaf *hoeffdingNode) {
+	if len(leaf.attributes) == 0 || h.opts.MaxDepth > 0 && leaf.depth >= h.opts.MaxDepth {
+		return
+	}
+	var present int
+	var n float64
+	for _, count := range leaf.classCounts {
+		if count > 0 {
+			present++
+			n += count
+		}
+	}
+	if present < 2 {
+		return
+	}
+	stats := h.attributeTypes(leaf)
+	best := getBestGainAttribute(stats)
+	// with one attribute, the runner-up is not splitting at all, which gains nothing
+	var second float64
+	for _, at := range stats {
+		if at.index != best.index && at.Gain > second {
+			second = at.Gain
+		}
+	}
+	// information gain ranges up to the entropy of a uniform distribution over the targets
+	r := math.Log2(float64(len(leaf.classCounts)))
+	bound := math.Sqrt(r * r * math.Log(1/h.opts.Delta) / (2 * n))
+	if best.Gain <= 0 || best.Gain-second <= bound && bound >= h.opts.TieThreshold {
+		return
+	}
+	remaining := make([]int, 0, len(leaf.attributes)-1)
+	for _, a := range leaf.attributes {
+		if a != best.index {
+			remaining = append(remaining, a)
+		}
+	}
+	leaf.split, leaf.splitStats = best, stats
+	leaf.children = make([]*hoeffdingNode, len(best.Values))
+	for code := range leaf.children {
+		leaf.children[code] = h.newLeaf(remaining, leaf.depth+1)
+	}
+	leaf.valueCounts = nil
+}
+
+// attributeTypes returns the gain of each attribute a leaf may split on given the
+// examples it has received.
+func (h *HoeffdingTree) attributeTypes(leaf *hoeffdingNode) AttributeTypes {
+	d := h.encoder.dataset
+	var presentTargets []int
+	var presentCounts []float64
+	for code, count := range leaf.classCounts {
+		if count > 0 {
+			presentTargets = append(presentTargets, code)
+			presentCounts = append(presentCounts, count)
+		}
+	}
+	setEntropy := entropy(presentCounts)
+	attributeTypes := make(AttributeTypes, len(leaf.attributes))
+	for i, attribute := range leaf.attributes {
+		m, _ := entropyMeasure(leaf.valueCounts[i], presentTargets)
+		values := d.AttributeTypes[attribute].Values
+		attrValues := make(AttributeValues, len(values))
+		for code, v := range values {
+			impurity, total := m([]int{code})
+			attrValues[code] = AttributeValue{Value: v, Entropy: impurity, Occurrences: total}
+		}
+		attributeTypes[i] = AttributeType{
+			Name:   d.AttributeTypes[attribute].Name,
+			Gain:   gain(setEntropy, attrValues...),
+			Values: attrValues,
+			index:  attribute,
+		}
+	}
+
+	return attributeTypes
+}
+
+// Snapshot returns the tree grown so far as a Node, which classifies, exports and
+// is drawn by the viewer like a built tree. Leaves are labelled with the most
+// common target of their examples, or of their parent's when they have none yet.
+// A node that has split reports the statistics of its attributes when it split.
+func (h *HoeffdingTree) Snapshot() Node {
+	return h.snapshot(h.root, "", nil)
+}
+
+func (h *HoeffdingTree) snapshot(n *hoeffdingNode, filterValue string, parent *hoeffdingNode) Node {
+	d := h.encoder.dataset
+	node := Node{
+		FilterValue:  filterValue,
+		TargetCounts: make(map[string]float64),
+		Terminal:     n.children == nil,
+	}
+	var presentCounts []float64
+	for code, count := range n.classCounts {
+		if count > 0 {
+			node.TargetCounts[d.Targets[code]] = count
+			node.Sample.Targets = append(node.Sample.Targets, d.Targets[code])
+			presentCounts = append(presentCounts, count)
+		}
+	}
+	node.Sample.Entropy = entropy(presentCounts)
+	if !node.Terminal {
+		node.Label = n.split.Name
+		node.Sample.AttributeTypes, node.Sample.BestGainAttribute = n.splitStats, n.split
+		node.Children = make([]Node, len(n.children))
+		for code, child := range n.children {
+			node.Children[code] = h.snapshot(child, d.AttributeTypes[n.split.index].Values[code], n)
+		}
+		return node
+	}
+	if len(presentCounts) > 0 {
+		node.Sample.AttributeTypes = h.attributeTypes(n)
+	}
+	counts := n.classCounts
+	if len(presentCounts) == 0 && parent != nil {
+		counts = parent.classCounts
+	}
+	var highest float64
+	for code, count := range counts {
+		if count > highest {
+			node.Label, highest = d.Targets[code], count
+		}
+	}
+
+	return node
+}
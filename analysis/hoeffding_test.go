@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"encoding/json"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"testing"
+)
+
+func TestHoeffdingTree(t *testing.T) {
+	stream := syntheticSample(30000, 8)
+	h, err := NewHoeffdingTree(stream, HoeffdingOptions{})
+	if err != nil {
+		t.Fatalf("creating Hoeffding tree: %v", err)
+	}
+	if root := h.Snapshot(); !root.Terminal {
+		t.Errorf("expected a tree without examples to be a leaf, got %s", root.Label)
+	}
+	// the last examples are held out for testing
+	train, test := stream.Examples[:25000], stream.Examples[25000:]
+	for i, eg := range train {
+		if err = h.Learn(eg); err != nil {
+			t.Fatalf("learning example %d: %v", i, err)
+		}
+	}
+	if h.Seen() != 25000 {
+		t.Errorf("expected 25000 examples to be seen, got %v", h.Seen())
+	}
+	// the target depends on a0, a1 and a2, so one of them is split on first
+	tree := h.Snapshot()
+	switch tree.Label {
+	case "a0", "a1", "a2":
+	default:
+		t.Errorf("expected the root to split on a0, a1 or a2, got %s", tree.Label)
+	}
+	if tree.TargetCounts["yes"]+tree.TargetCounts["no"] != 25000 {
+		t.Errorf("expected the root to count every example, got %v", tree.TargetCounts)
+	}
+	// a snapshot is encoded and classifies like a built tree
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("encoding snapshot: %v", err)
+	}
+	var decoded Node
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding snapshot: %v", err)
+	}
+	var correct int
+	for _, eg := range test {
+		label, err := decoded.ClassifyExample(stream.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example: %v", err)
+		}
+		if label == eg.Target {
+			correct++
+		}
+	}
+	if accuracy := float64(correct) / float64(len(test)); accuracy < 0.9 {
+		t.Errorf("expected an accuracy of at least 0.9, got %v", accuracy)
+	}
+}
+
+func TestHoeffdingTree_Options(t *testing.T) {
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	// a grace period of one example and a generous tie threshold split as soon as
+	// any attribute gains
+	h, err := NewHoeffdingTree(sample, HoeffdingOptions{GracePeriod: 1, TieThreshold: 10, MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("creating Hoeffding tree: %v", err)
+	}
+	for _, eg := range sample.Examples {
+		if err = h.Learn(eg); err != nil {
+			t.Fatalf("learning example: %v", err)
+		}
+	}
+	tree := h.Snapshot()
+	if tree.Terminal || len(tree.Children) == 0 {
+		t.Fatal("expected the root to split")
+	}
+	for _, child := range tree.Children {
+		if !child.Terminal {
+			t.Errorf("expected a maximum depth of one to keep %s a leaf", child.FilterValue)
+		}
+		if child.Label == "" {
+			t.Errorf("expected leaf %s to be labelled", child.FilterValue)
+		}
+	}
+	invalid := sample.Examples[0]
+	invalid.Target = "Maybe"
+	if err = h.Learn(invalid); err == nil {
+		t.Error("expected an error for an unknown target")
+	}
+	if _, err = NewHoeffdingTree(sample, HoeffdingOptions{Delta: 2}); err == nil {
+		t.Error("expected an error for a delta above one")
+	}
+}
@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"sort"
+)
+
+// Importance is how much an attribute's splits contribute to a tree.
+type Importance struct {
+	Attribute string
+	// Gain is the sum of the gain of each split on the attribute, weighed by the
+	// share of the training weight that reached the split. For a regression tree it
+	// is the weighted reduction in impurity.
+	Gain float64
+	// Share is Gain as a fraction of the weighted gain of every split of the tree
+	Share float64
+	// Splits is the number of nodes that split on the attribute
+	Splits int
+}
+
+// Importance returns the importance of every attribute the root could split on,
+// most important first, with attributes of equal importance in declared order. It
+// only uses what the nodes record, so it also works for trees read back from json.
+func (n Node) Importance() []Importance {
+	var importances []Importance
+	index := make(map[string]int)
+	for _, at := range n.Sample.AttributeTypes {
+		index[at.Name] = len(importances)
+		importances = append(importances, Importance{Attribute: at.Name})
+	}
+	rootWeight := n.weight()
+	var total float64
+	var visit func(node Node)
+	visit = func(node Node) {
+		if node.Terminal || len(node.Children) == 0 {
+			return
+		}
+		name := node.Sample.BestGainAttribute.Name
+		if name == "" {
+			name = node.Label
+		}
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(importances)
+			i = len(importances)
+			importances = append(importances, Importance{Attribute: name})
+		}
+		var gain float64
+		if rootWeight > 0 {
+			gain = node.weight() / rootWeight * node.Sample.BestGainAttribute.Gain
+		}
+		importances[i].Gain += gain
+		importances[i].Splits++
+		total += gain
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(n)
+	for i := range importances {
+		if total > 0 {
+			importances[i].Share = importances[i].Gain / total
+		}
+	}
+	sort.SliceStable(importances, func(i, j int) bool {
+		return importances[i].Gain > importances[j].Gain
+	})
+
+	return importances
+}
+
+// weight returns the total weight of the training examples that reached the node.
+func (n Node) weight() float64 {
+	if n.Summary != nil {
+		return n.Summary.Count
+	}
+	var total float64
+	for _, count := range n.TargetCounts {
+		total += count
+	}
+
+	return total
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"encoding/json"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNode_Importance(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %v", err)
+	}
+	importances := tree.Importance()
+	if len(importances) != 4 {
+		t.Fatalf("expected an importance for each of 4 attributes, got %v", importances)
+	}
+	// the root's split sees every example, so its attribute gains at least the root's gain
+	if importances[0].Attribute != tree.Label || importances[0].Gain < tree.Sample.BestGainAttribute.Gain {
+		t.Errorf("expected the root attribute %s to be most important, got %v", tree.Label, importances)
+	}
+	var share float64
+	var splits int
+	for i, imp := range importances {
+		share += imp.Share
+		splits += imp.Splits
+		if i > 0 && imp.Gain > importances[i-1].Gain {
+			t.Errorf("expected importances sorted by gain, got %v", importances)
+		}
+	}
+	if math.Abs(share-1) > 1e-9 {
+		t.Errorf("expected shares to sum to one, got %v", share)
+	}
+	if want := Root(tree).CountNodes() - countLeaves(tree); splits != want {
+		t.Errorf("expected %d splits, got %d", want, splits)
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("encoding tree: %v", err)
+	}
+	var decoded Node
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding tree: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Importance(), importances) {
+		t.Errorf("expected the same importance from json, got %v", decoded.Importance())
+	}
+}
+
+func TestNode_Importance_Regression(t *testing.T) {
+	quietly(t)
+	sample, err := parse.Parse(strings.NewReader(regressionData))
+	if err != nil {
+		t.Fatalf("parsing regression sample: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %v", err)
+	}
+	importances := tree.Importance()
+	// season separates the long summer hours from the short winter ones
+	if importances[0].Attribute != "season" || importances[0].Gain <= 0 {
+		t.Errorf("expected season to be most important, got %v", importances)
+	}
+}
+
+func countLeaves(n Node) int {
+	if len(n.Children) == 0 {
+		return 1
+	}
+	var leaves int
+	for _, child := range n.Children {
+		leaves += countLeaves(child)
+	}
+
+	return leaves
+}
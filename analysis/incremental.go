@@ -0,0 +1,363 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+)
+
+// Incremental keeps a tree up to date as labelled examples arrive, in the manner
+// of ID5R (Utgoff, 1989), instead of building it again from every example. Each
+// node keeps the weight of its examples by target and by value and target of each
+// remaining attribute, so adding an example only updates the counts of the nodes
+// on its path. Where the example changes which attribute a node is best split by,
+// that attribute is pulled up to the node by transposing it with the splits below,
+// and the subtrees under them are reused. A node split in two, as by an ordinal cut
+// or binary splits, and the nodes of a regression tree, are instead built again from
+// the examples they hold. The tree is the one BuildTreeContext would build from
+// every example added so far, except that counts of fractional weights or class
+// weights, summed in a different order, can differ in the last bits and so tip a
+// near tie between splits the other way.
+type Incremental struct {
+	encoder *encoder
+	builder builder
+	// multipliers scales the weight of each target's examples by its class weight
+	multipliers []float64
+	tree        Node
+}
+
+// NewIncremental builds a tree from the sample that examples can be added to. The
+// options are those of BuildTreeContext, except that the node and memory budgets
+// and balanced class weights, which depend on every example, are not supported.
+func NewIncremental(ctx context.Context, sample parse.Sample, opts Options) (*Incremental, error) {
+	if opts.MaxNodes > 0 || opts.MaxMemory > 0 || opts.BalancedClassWeights {
+		return nil, fmt.Errorf("budgets and balanced class weights do not apply to incremental trees")
+	}
+	if len(sample.Examples) == 0 {
+		return nil, fmt.Errorf("building incremental tree: the sample has no examples")
+	}
+	enc := newEncoder(sample, len(sample.Examples))
+	for row, eg := range sample.Examples {
+		if err := enc.add(eg); err != nil {
+			return nil, fmt.Errorf("encoding example %d: %w", row, err)
+		}
+	}
+	b, d, err := enc.dataset.newBuilder(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	// examples are added to the dataset with the class weights applied
+	enc.dataset = d
+	inc := &Incremental{encoder: enc, builder: *b, multipliers: make([]float64, len(d.Targets))}
+	for code, target := range d.Targets {
+		inc.multipliers[code] = 1
+		if w, ok := opts.ClassWeights[target]; ok {
+			inc.multipliers[code] = w
+		}
+	}
+	inc.tree, err = b.build(newSubset(d, d.allRows(), d.nominalAttributes(), splitOptions{
+		binarySplits: opts.BinarySplits,
+		criterion:    opts.Criterion,
+		incremental:  true,
+	}), "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("building root node: %w", err)
+	}
+
+	return inc, nil
+}
+
+// Tree returns the tree built from every example added so far.
+func (inc *Incremental) Tree() Node {
+	return inc.tree
+}
+
+// Add updates the tree with a new example, which must have the attribute types and
+// targets of the sample the tree was built from. When adding fails, as when the
+// context is cancelled while a subtree is restructured, the tree is left as it was.
+func (inc *Incremental) Add(ctx context.Context, eg parse.Example) error {
+	d := inc.encoder.dataset
+	row := d.Len()
+	if err := inc.encoder.add(eg); err != nil {
+		return fmt.Errorf("encoding example: %w", err)
+	}
+	if !d.regression() {
+		d.weights[row] *= inc.multipliers[d.targets[row]]
+	}
+	b := inc.builder
+	b.ctx = ctx
+	tree, err := b.update(inc.tree, nil, row)
+	if err != nil {
+		d.truncate(row)
+		return fmt.Errorf("adding example: %w", err)
+	}
+	inc.tree = tree
+
+	return nil
+}
+
+// update returns a copy of the node with a row of the dataset added, which its
+// parent holds and which belongs in the node. While the node's best split stays the
+// same, only the child the row belongs in and the empty leaves labelled from the
+// node's examples change. When a node that splits into one branch per value comes
+// to be best split by another such attribute, the row is added to the child it
+// belongs in and the new attribute is pulled up through the subtree by transpose.
+// Otherwise, as for a leaf that now splits or a split of values in two, the node
+// is built again from its examples.
+func (b *builder) update(node Node, parent *Node, row int) (Node, error) {
+	if err := b.ctx.Err(); err != nil {
+		return Node{}, err
+	}
+	s := node.Sample.withRow(row)
+	current := node.Sample.BestGainAttribute
+	switch {
+	case !node.Terminal && s.BestGainAttribute.sameSplit(current):
+	case !node.Terminal && s.transposable(current) && s.transposable(s.BestGainAttribute):
+		fmt.Fprintln(Output, "transposing node", node.FilterValue, current.Name, "to", s.BestGainAttribute.Name)
+		node.parent = parent
+		node.Sample = s
+		node.TargetCounts = s.TargetCounts()
+		children := make([]Node, len(node.Children))
+		copy(children, node.Children)
+		code := s.dataset.columns[current.index][row]
+		var err error
+		if children[code], err = b.update(children[code], &node, row); err != nil {
+			return Node{}, err
+		}
+		node.Children = children
+		return b.transpose(node, current.index, parent)
+	default:
+		fmt.Fprintln(Output, "restructuring node", node.FilterValue, node.Label)
+		rebuilt, err := b.build(s, node.FilterValue, parent)
+		rebuilt.FilterOp, rebuilt.FilterValues = node.FilterOp, node.FilterValues
+		return rebuilt, err
+	}
+	node.Sample = s
+	node.TargetCounts = s.TargetCounts()
+	if s.dataset.regression() {
+		node.Summary = s.summary()
+	}
+	branch := s.branchOf(row)
+	children := make([]Node, len(node.Children))
+	copy(children, node.Children)
+	for i, child := range children {
+		var err error
+		switch {
+		case i == branch:
+			children[i], err = b.update(child, &node, row)
+		case len(child.Sample.rows) == 0:
+			// an empty leaf is labelled from its parent's examples, which have changed
+			children[i], err = b.build(child.Sample, child.FilterValue, &node)
+			children[i].FilterOp, children[i].FilterValues = child.FilterOp, child.FilterValues
+		}
+		if err != nil {
+			return Node{}, err
+		}
+	}
+	node.Children = children
+
+	return node, nil
+}
+
+// transposable reports whether a subtree split by the attribute can be transposed
+// with the subtrees below it: only a classification split into one branch per value
+// can, since a split of values in two leaves the attribute available below it.
+func (s Sample) transposable(at AttributeType) bool {
+	return at.first == nil && at.Name != "" && !s.dataset.regression()
+}
+
+// transpose returns the node that build would make of node's sample, given the node
+// split by the attribute at dataset index split into one child per value. Each child
+// must already be the node build would make of its sample, apart from the labels of
+// empty leaves, which come from the node's examples. When the sample is best split
+// by another attribute, that attribute is pulled up to the node and the subtrees
+// below are reused, so that only the nodes between them are counted again.
+func (b *builder) transpose(node Node, split int, parent *Node) (Node, error) {
+	s := node.Sample
+	if len(s.rows) == 0 || len(s.Targets) == 1 || b.exhausted(s, parent) || !s.transposable(s.BestGainAttribute) {
+		rebuilt, err := b.build(s, node.FilterValue, parent)
+		rebuilt.FilterOp, rebuilt.FilterValues = node.FilterOp, node.FilterValues
+		return rebuilt, err
+	}
+	if best := s.BestGainAttribute.index; best != split {
+		var err error
+		if node, err = b.pullUp(node, split, best, parent); err != nil {
+			return Node{}, err
+		}
+	}
+	node.parent = parent
+	node.Label = s.BestGainAttribute.Name
+	node.Terminal = false
+	children := make([]Node, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = child
+		if len(child.Sample.rows) == 0 {
+			var err error
+			if children[i], err = b.build(child.Sample, child.FilterValue, &node); err != nil {
+				return Node{}, err
+			}
+		}
+	}
+	node.Children = children
+	fmt.Fprintln(Output, "completed node", node.FilterValue, node.Label)
+
+	return node, nil
+}
+
+// pullUp returns the node split by the attribute at dataset index to instead of the
+// one at split, in the manner of ID5R: each child is itself split by to, and the
+// grandchildren having each value of to are gathered under a new child split by
+// split, which transpose then corrects. The grandchildren hold the same examples
+// and remaining attributes at the same depth as before, so they are reused as they
+// are, apart from the labels of empty leaves.
+func (b *builder) pullUp(node Node, split, to int, parent *Node) (Node, error) {
+	if err := b.ctx.Err(); err != nil {
+		return Node{}, err
+	}
+	d := node.Sample.dataset
+	node.parent = parent
+	pulled := make([]Node, len(node.Children))
+	for x, child := range node.Children {
+		var err error
+		if pulled[x], err = b.splitBy(child, to, &node); err != nil {
+			return Node{}, err
+		}
+	}
+	n := Node{
+		parent:       parent,
+		Sample:       node.Sample,
+		FilterValue:  node.FilterValue,
+		FilterOp:     node.FilterOp,
+		FilterValues: node.FilterValues,
+		Label:        d.AttributeTypes[to].Name,
+		TargetCounts: node.TargetCounts,
+	}
+	toValues, splitValues := d.AttributeTypes[to].Values, d.AttributeTypes[split].Values
+	subsets := node.Sample.splitBy(to)
+	n.Children = make([]Node, len(subsets))
+	for v, subset := range subsets {
+		gathered := Node{
+			parent:       &n,
+			Sample:       subset,
+			FilterValue:  toValues[v],
+			Label:        d.AttributeTypes[split].Name,
+			TargetCounts: subset.TargetCounts(),
+			Children:     make([]Node, len(pulled)),
+		}
+		for x, p := range pulled {
+			grandchild := p.Children[v]
+			grandchild.FilterValue, grandchild.FilterOp, grandchild.FilterValues = splitValues[x], "", nil
+			gathered.Children[x] = grandchild
+		}
+		var err error
+		if n.Children[v], err = b.transpose(gathered, split, &n); err != nil {
+			return Node{}, err
+		}
+	}
+
+	return n, nil
+}
+
+// splitBy returns the child split by the attribute at dataset index to, with each
+// of its children being the node build would make of its sample. A child split into
+// one branch per value has the attribute pulled up to it, and any other child has
+// its examples divided by the attribute and each part built.
+func (b *builder) splitBy(child Node, to int, parent *Node) (Node, error) {
+	at := child.Sample.BestGainAttribute
+	if !child.Terminal && len(child.Children) > 0 && child.Sample.transposable(at) {
+		if at.index == to {
+			return child, nil
+		}
+		return b.pullUp(child, at.index, to, parent)
+	}
+	d := child.Sample.dataset
+	n := Node{
+		parent:       parent,
+		Sample:       child.Sample,
+		FilterValue:  child.FilterValue,
+		FilterOp:     child.FilterOp,
+		FilterValues: child.FilterValues,
+		Label:        d.AttributeTypes[to].Name,
+		TargetCounts: child.TargetCounts,
+	}
+	subsets := child.Sample.splitBy(to)
+	n.Children = make([]Node, len(subsets))
+	for v, subset := range subsets {
+		var err error
+		if n.Children[v], err = b.build(subset, d.AttributeTypes[to].Values[v], &n); err != nil {
+			return Node{}, err
+		}
+	}
+
+	return n, nil
+}
+
+// withRow returns the sample with a row of its dataset added, updating its counts
+// rather than counting its rows again.
+func (s Sample) withRow(row int) Sample {
+	d := s.dataset
+	w := d.weights[row]
+	s.rows = append(s.rows, row)
+	s.classCounts = append([]float64(nil), s.classCounts...)
+	if !d.regression() {
+		target := d.targets[row]
+		s.classCounts[target] += w
+		// the counts are shared with earlier copies of the sample, so only the
+		// counts that change are copied before they are changed
+		valueCounts := make([][][]float64, len(s.valueCounts))
+		for i, counts := range s.valueCounts {
+			code := d.columns[s.attributes[i]][row]
+			valueCounts[i] = append([][]float64(nil), counts...)
+			valueCounts[i][code] = append([]float64(nil), counts[code]...)
+			valueCounts[i][code][target] += w
+		}
+		s.valueCounts = valueCounts
+	}
+	s.updateStatistics()
+
+	return s
+}
+
+// branchOf returns the index of the subset that split puts a row of the sample in.
+func (s Sample) branchOf(row int) int {
+	at := s.BestGainAttribute
+	code := s.dataset.columns[at.index][row]
+	switch {
+	case at.first == nil:
+		return code
+	case at.first[code]:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// sameSplit reports whether splitting by either attribute type divides a sample
+// into the same subsets.
+func (at AttributeType) sameSplit(other AttributeType) bool {
+	if at.Name != other.Name || at.index != other.index || at.Cut != other.Cut || len(at.first) != len(other.first) {
+		return false
+	}
+	for code := range at.first {
+		if at.first[code] != other.first[code] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// truncate removes the rows of the dataset from the given row on.
+func (d *Dataset) truncate(rows int) {
+	for i, column := range d.columns {
+		if column != nil {
+			d.columns[i] = column[:rows]
+		}
+	}
+	if d.regression() {
+		d.values = d.values[:rows]
+	} else {
+		d.targets = d.targets[:rows]
+	}
+	d.weights = d.weights[:rows]
+}
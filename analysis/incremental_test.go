@@ -0,0 +1,255 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestIncremental_MatchesRebuild(t *testing.T) {
+	quietly(t)
+	load := func(filename, schemaFile string) parse.Sample {
+		t.Helper()
+		var sample parse.Sample
+		var err error
+		if schemaFile != "" {
+			schema, schemaErr := parse.SchemaFromFile(schemaFile)
+			if schemaErr != nil {
+				t.Fatalf("reading schema %s: %v", schemaFile, schemaErr)
+			}
+			sample, err = parse.FromFileWithSchema(filename, schema, parse.ParseOptions{})
+		} else {
+			sample, err = parse.FromFile(filename)
+		}
+		if err != nil {
+			t.Fatalf("failed parsing file %s: %v", filename, err)
+		}
+		return sample
+	}
+	lenses, fishing := load("../data/contact-lenses.data.txt", ""), load("../data/fishing.data.txt", "")
+	tests := []struct {
+		name   string
+		sample parse.Sample
+		opts   Options
+	}{
+		{"contact lenses", lenses, Options{}},
+		{"fishing", fishing, Options{}},
+		{"binary splits", fishing, Options{BinarySplits: true}},
+		{"class weights", lenses, Options{ClassWeights: map[string]float64{"hard": 3}, MaxDepth: 3}},
+		{"synthetic", syntheticSample(400, 6), Options{Workers: 4}},
+		{"regression", load("../data/fishing-catch.csv", "../data/fishing-catch.schema.json"), Options{Criterion: MAE}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initial := test.sample
+			initial.Examples = test.sample.Examples[:1]
+			inc, err := NewIncremental(context.Background(), initial, test.opts)
+			if err != nil {
+				t.Fatalf("building incremental tree: %v", err)
+			}
+			for i, eg := range test.sample.Examples[1:] {
+				if err = inc.Add(context.Background(), eg); err != nil {
+					t.Fatalf("adding example %d: %v", i+1, err)
+				}
+				// a rebuild after every example is slow for the larger samples
+				if len(test.sample.Examples) > 100 && i%50 != 0 && i != len(test.sample.Examples)-2 {
+					continue
+				}
+				sofar := test.sample
+				sofar.Examples = test.sample.Examples[:i+2]
+				rebuilt, err := BuildTreeContext(context.Background(), sofar, test.opts)
+				if err != nil {
+					t.Fatalf("rebuilding tree: %v", err)
+				}
+				want, _ := json.Marshal(rebuilt)
+				got, _ := json.Marshal(inc.Tree())
+				if string(got) != string(want) {
+					t.Fatalf("after %d examples the incremental tree differs from a rebuild:\n%s\nwant:\n%s", i+2, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIncremental_Add(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	if _, err = NewIncremental(context.Background(), sample, Options{MaxNodes: 10}); err == nil {
+		t.Error("expected an error for a node budget")
+	}
+	inc, err := NewIncremental(context.Background(), sample, Options{})
+	if err != nil {
+		t.Fatalf("building incremental tree: %v", err)
+	}
+	before, _ := json.Marshal(inc.Tree())
+	invalid := sample.Examples[0]
+	invalid.StringValues = append([]string{"Gale"}, invalid.StringValues[1:]...)
+	if err = inc.Add(context.Background(), invalid); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = inc.Add(ctx, sample.Examples[0]); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+	if after, _ := json.Marshal(inc.Tree()); string(after) != string(before) {
+		t.Error("expected failed additions to leave the tree unchanged")
+	}
+	// the tree and dataset stay consistent after failures
+	extended := sample
+	extended.Examples = append(append(parse.Examples(nil), sample.Examples...), sample.Examples[0])
+	if err = inc.Add(context.Background(), sample.Examples[0]); err != nil {
+		t.Fatalf("adding example: %v", err)
+	}
+	rebuilt, err := BuildTree(extended)
+	if err != nil {
+		t.Fatalf("rebuilding tree: %v", err)
+	}
+	want, _ := json.Marshal(rebuilt)
+	if got, _ := json.Marshal(inc.Tree()); string(got) != string(want) {
+		t.Errorf("expected the incremental tree to match a rebuild after failed additions")
+	}
+}
+
+func TestIncremental_ReusesSubtrees(t *testing.T) {
+	quietly(t)
+	sample, err := parse.Parse(strings.NewReader(`2
+yes,no
+2
+a,2,0,1
+b,2,0,1
+10
+0,0,no
+0,0,no
+0,0,no
+0,1,yes
+0,1,yes
+1,0,yes
+1,0,yes
+1,0,yes
+1,1,yes
+1,1,yes
+`))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	inc, err := NewIncremental(context.Background(), sample, Options{})
+	if err != nil {
+		t.Fatalf("building incremental tree: %v", err)
+	}
+	// sameNode reports whether two nodes hold the very same rows, rather than an
+	// equal copy of them
+	sameNode := func(a, b Node) bool {
+		return len(a.Sample.rows) > 0 && len(a.Sample.rows) == len(b.Sample.rows) && &a.Sample.rows[0] == &b.Sample.rows[0]
+	}
+	add := func(eg parse.Example) {
+		t.Helper()
+		if err := inc.Add(context.Background(), eg); err != nil {
+			t.Fatalf("adding example: %v", err)
+		}
+		sample.Examples = append(sample.Examples, eg)
+		rebuilt, err := BuildTree(sample)
+		if err != nil {
+			t.Fatalf("rebuilding tree: %v", err)
+		}
+		want, _ := json.Marshal(rebuilt)
+		if got, _ := json.Marshal(inc.Tree()); string(got) != string(want) {
+			t.Fatalf("incremental tree differs from a rebuild:\n%s\nwant:\n%s", got, want)
+		}
+	}
+
+	before := inc.Tree()
+	if before.Label != "a" || before.Children[0].Label != "b" {
+		t.Fatalf("expected the root to split on a and a=0 on b, got %s and %s", before.Label, before.Children[0].Label)
+	}
+	// an example reaching a=1 leaves the a=0 branch as it was
+	add(parse.Example{StringValues: []string{"1", "0"}, Target: "yes"})
+	if after := inc.Tree(); !sameNode(after.Children[0], before.Children[0]) {
+		t.Error("expected the a=0 subtree to be reused")
+	}
+
+	// examples of a=0, b=1 make b the better split at the root, which is pulled up
+	// above a, carrying the a=0, b=0 leaf along
+	reused := before.Children[0].Children[0]
+	for i := 0; i < 3; i++ {
+		add(parse.Example{StringValues: []string{"0", "1"}, Target: "yes"})
+	}
+	after := inc.Tree()
+	if after.Label != "b" || after.Children[0].Label != "a" {
+		t.Fatalf("expected the root to split on b and b=0 on a, got %s and %s", after.Label, after.Children[0].Label)
+	}
+	if !sameNode(after.Children[0].Children[0], reused) {
+		t.Error("expected the a=0, b=0 leaf to be reused below the transposed root")
+	}
+}
+
+// randomSample returns a small sample of nominal and ordinal attributes whose targets
+// follow the first attribute with noise, weighted by whole numbers so that the
+// counts sum exactly in any order.
+func randomSample(rng *rand.Rand) parse.Sample {
+	sample := parse.Sample{Targets: parse.Targets{"yes", "no", "maybe"}[:2+rng.Intn(2)]}
+	sample.NumTargets = len(sample.Targets)
+	sample.NumAttributes = 2 + rng.Intn(3)
+	for i := 0; i < sample.NumAttributes; i++ {
+		at := parse.AttributeType{Name: fmt.Sprintf("a%d", i), NumValues: 2 + rng.Intn(3), Ordinal: rng.Intn(4) == 0}
+		for v := 0; v < at.NumValues; v++ {
+			at.Values = append(at.Values, fmt.Sprintf("v%d", v))
+		}
+		sample.AttributeTypes = append(sample.AttributeTypes, at)
+	}
+	sample.NumExamples = 2 + rng.Intn(40)
+	for r := 0; r < sample.NumExamples; r++ {
+		eg := parse.Example{Weight: float64(rng.Intn(3))}
+		for _, at := range sample.AttributeTypes {
+			eg.StringValues = append(eg.StringValues, at.Values[rng.Intn(at.NumValues)])
+		}
+		eg.Target = sample.Targets[len(eg.StringValues[0])%sample.NumTargets]
+		if eg.StringValues[0] == "v1" || rng.Intn(3) == 0 {
+			eg.Target = sample.Targets[rng.Intn(sample.NumTargets)]
+		}
+		sample.Examples = append(sample.Examples, eg)
+	}
+
+	return sample
+}
+
+func TestIncremental_Random(t *testing.T) {
+	quietly(t)
+	for seed := int64(0); seed < 500; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		sample := randomSample(rng)
+		opts := Options{BinarySplits: rng.Intn(3) == 0, MaxDepth: rng.Intn(4)}
+		if rng.Intn(3) == 0 {
+			opts.ClassWeights = map[string]float64{sample.Targets[0]: 2}
+		}
+		initial := sample
+		initial.Examples = sample.Examples[:1]
+		inc, err := NewIncremental(context.Background(), initial, opts)
+		if err != nil {
+			t.Fatalf("seed %d: building incremental tree: %v", seed, err)
+		}
+		for i, eg := range sample.Examples[1:] {
+			if err = inc.Add(context.Background(), eg); err != nil {
+				t.Fatalf("seed %d: adding example %d: %v", seed, i+1, err)
+			}
+			sofar := sample
+			sofar.Examples = sample.Examples[:i+2]
+			rebuilt, err := BuildTreeContext(context.Background(), sofar, opts)
+			if err != nil {
+				t.Fatalf("seed %d: rebuilding tree: %v", seed, err)
+			}
+			want, _ := json.Marshal(rebuilt)
+			if got, _ := json.Marshal(inc.Tree()); string(got) != string(want) {
+				t.Fatalf("seed %d: after %d examples the incremental tree differs from a rebuild:\n%s\nwant:\n%s", seed, i+2, got, want)
+			}
+		}
+	}
+}
@@ -6,23 +6,24 @@ import (
 
 func gain(entropySet float64, attrValues ...AttributeValue) float64 {
 	// for denominator under occurrences of each attribute value occurrence
-	var setSize int
+	var setSize float64
 	for _, av := range attrValues {
 		setSize += av.Occurrences
 	}
 
 	thisGain := entropySet
 	for _, av := range attrValues {
-		pOfValue := float64(av.Occurrences) / float64(setSize)
+		pOfValue := av.Occurrences / setSize
 		thisGain = thisGain - (pOfValue * av.Entropy)
 	}
 
 	return thisGain
 }
 
-func entropy(occurrences []int) float64 {
+// entropy of a set from the weighted occurrences of each target
+func entropy(occurrences []float64) float64 {
 	var entropy float64
-	var total int
+	var total float64
 	for _, occ := range occurrences {
 		// if any target has zero occurrences, entropy is 0
 		if occ == 0 {
@@ -32,7 +33,7 @@ func entropy(occurrences []int) float64 {
 	}
 	occurrenceRatios := make([]float64, len(occurrences))
 	for i := range occurrences {
-		occurrenceRatios[i] = float64(occurrences[i]) / float64(total)
+		occurrenceRatios[i] = occurrences[i] / total
 	}
 
 	for _, pOfTarget := range occurrenceRatios {
@@ -41,3 +42,25 @@ func entropy(occurrences []int) float64 {
 
 	return entropy
 }
+
+// gainRatio divides the gain of a split by its split information, the entropy of
+// the division of the set among the attribute values, so that splits into many
+// small subsets are not favoured.
+func gainRatio(gain float64, attrValues ...AttributeValue) float64 {
+	var setSize float64
+	for _, av := range attrValues {
+		setSize += av.Occurrences
+	}
+	var splitInformation float64
+	for _, av := range attrValues {
+		if av.Occurrences > 0 {
+			pOfValue := av.Occurrences / setSize
+			splitInformation -= pOfValue * math.Log2(pOfValue)
+		}
+	}
+	if splitInformation == 0 {
+		return 0
+	}
+
+	return gain / splitInformation
+}
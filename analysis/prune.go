@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+)
+
+// Prune replaces each subtree with a leaf wherever that does not increase the cost
+// of classifying the validation sample, working up from the lowest subtrees. This is
+// reduced error pruning, with each error weighed by its cost when costs are given and
+// counting one otherwise. A subtree that becomes a leaf is labelled with the target
+// of least expected cost given the training examples that reached it. Validation
+// examples the subtree has no branch for cost the same either way. Prune returns the
+// number of nodes removed.
+func (n *Node) Prune(validation parse.Sample, costs *CostMatrix) (int, error) {
+	if validation.RealTarget != "" {
+		return 0, fmt.Errorf("pruning: regression trees cannot be pruned")
+	}
+	if costs == nil {
+		costs = NewCostMatrix(validation.Targets)
+	}
+	if err := costs.covers(validation.Targets); err != nil {
+		return 0, fmt.Errorf("pruning: %w", err)
+	}
+	examples := make([]validationExample, len(validation.Examples))
+	for i, eg := range validation.Examples {
+		values, err := validation.AttributeTypes.ExampleValues(eg)
+		if err != nil {
+			return 0, fmt.Errorf("pruning with example %d: %w", i, err)
+		}
+		examples[i] = validationExample{values: values, target: eg.Target, weight: eg.EffectiveWeight()}
+	}
+	_, removed := n.prune(examples, costs)
+
+	return removed, nil
+}
+
+type validationExample struct {
+	values map[string]string
+	target string
+	weight float64
+}
+
+// prune prunes the subtree below n and returns the cost of classifying the examples
+// with what remains, along with the number of nodes removed.
+func (n *Node) prune(examples []validationExample, costs *CostMatrix) (float64, int) {
+	if n.Terminal {
+		return leafCost(n.Label, examples, costs), 0
+	}
+	label := costs.MinCostTarget(n.TargetCounts)
+	byChild := make([][]validationExample, len(n.Children))
+	var unmatched []validationExample
+	for _, eg := range examples {
+		matched := false
+		if value, ok := eg.values[n.Label]; ok {
+			for i := range n.Children {
+				if n.Children[i].Admits(value) {
+					byChild[i] = append(byChild[i], eg)
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, eg)
+		}
+	}
+	subtreeCost := leafCost(label, unmatched, costs)
+	var removed int
+	for i := range n.Children {
+		cost, childRemoved := n.Children[i].prune(byChild[i], costs)
+		subtreeCost += cost
+		removed += childRemoved
+	}
+	if cost := leafCost(label, examples, costs); cost <= subtreeCost {
+		removed += Root(*n).CountNodes() - 1
+		n.Children = nil
+		n.Label = label
+		n.Terminal = true
+		return cost, removed
+	}
+
+	return subtreeCost, removed
+}
+
+// leafCost returns the cost of predicting the label for every example.
+func leafCost(label string, examples []validationExample, costs *CostMatrix) float64 {
+	var cost float64
+	for _, eg := range examples {
+		c, err := costs.Cost(eg.target, label)
+		if err != nil {
+			continue
+		}
+		cost += c * eg.weight
+	}
+
+	return cost
+}
@@ -0,0 +1,216 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Criterion measures the impurity of a real target's values in a regression tree,
+// which each split is chosen to reduce the most.
+type Criterion string
+
+const (
+	// Variance is the weighted variance of the target values. Leaves predict the mean.
+	Variance Criterion = "variance"
+	// MAE is the weighted mean absolute deviation of the target values from their
+	// median. Leaves predict the median, which outliers move less than the mean.
+	MAE Criterion = "mae"
+)
+
+// Summary describes the real target values of the training examples that reached
+// a node of a regression tree.
+type Summary struct {
+	// Count is the total weight of the examples. It is zero for a leaf that no
+	// example reached, which has the mean and median of its parent's examples.
+	Count  float64
+	Mean   float64
+	Median float64
+}
+
+// regressionLeaf makes a leaf of a regression tree, labelled with the value it
+// predicts: the mean of its examples' targets under Variance, or their median
+// under MAE. A leaf without examples predicts what its parent would have.
+func (b *builder) regressionLeaf(s Sample, filterValue string, parent *Node) (Node, error) {
+	node := Node{
+		parent:      parent,
+		Sample:      s,
+		FilterValue: filterValue,
+		Terminal:    true,
+	}
+	if len(s.rows) > 0 {
+		node.Summary = s.summary()
+	} else {
+		if parent == nil {
+			return Node{}, fmt.Errorf("parent cannot be nil when there are no remaining examples")
+		}
+		summary := *parent.Summary
+		summary.Count = 0
+		node.Summary = &summary
+	}
+	prediction := node.Summary.Mean
+	if s.splitting.criterion == MAE {
+		prediction = node.Summary.Median
+	}
+	node.Label = strconv.FormatFloat(prediction, 'g', -1, 64)
+	fmt.Fprintln(Output, "completed node", node.FilterValue, node.Label)
+
+	return node, nil
+}
+
+// Predict returns the value that a regression tree predicts for values keyed by
+// attribute name, which is the label of the leaf they reach.
+func (n Node) Predict(values map[string]string) (float64, error) {
+	label, err := n.Classify(values)
+	if err != nil {
+		return 0, err
+	}
+	prediction, err := strconv.ParseFloat(label, 64)
+	if err != nil {
+		return 0, fmt.Errorf("predicting: leaf label %s is not a number: %w", label, err)
+	}
+
+	return prediction, nil
+}
+
+// PredictExample predicts the real target of a parsed example whose values are
+// laid out according to the given attribute types.
+func (n Node) PredictExample(attributeTypes parse.AttributeTypes, eg parse.Example) (float64, error) {
+	values, err := attributeTypes.ExampleValues(eg)
+	if err != nil {
+		return 0, fmt.Errorf("predicting example: %w", err)
+	}
+
+	return n.Predict(values)
+}
+
+// summary returns the count, mean and median of the sample's target values.
+func (s Sample) summary() *Summary {
+	d := s.dataset
+	count, mean := d.mean(s.rows)
+
+	return &Summary{Count: count, Mean: mean, Median: d.median(s.rows)}
+}
+
+// pure reports whether every example of a regression sample has the same target value.
+func (s Sample) pure() bool {
+	for _, row := range s.rows {
+		if s.dataset.values[row] != s.dataset.values[s.rows[0]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// regressionMeasure measures the spread of the target values of the examples
+// having an attribute's values by the sample's criterion, and orders the values
+// by the mean of their target values.
+func (s Sample) regressionMeasure(attribute int) (measure, func(code int) float64) {
+	d := s.dataset
+	numValues := len(d.AttributeTypes[attribute].Values)
+	column := d.columns[attribute]
+	// sums are taken about the sample's mean so that the variance keeps its precision
+	_, shift := d.mean(s.rows)
+	weights := make([]float64, numValues)
+	sums := make([]float64, numValues)
+	squares := make([]float64, numValues)
+	for _, row := range s.rows {
+		code, w, y := column[row], d.weights[row], d.values[row]-shift
+		weights[code] += w
+		sums[code] += w * y
+		squares[code] += w * y * y
+	}
+	order := func(code int) float64 {
+		return shift + sums[code]/weights[code]
+	}
+	if s.splitting.criterion == MAE {
+		parts := d.partition(attribute, s.rows)
+		return func(codes []int) (float64, float64) {
+			var rows []int
+			var total float64
+			for _, code := range codes {
+				rows = append(rows, parts[code]...)
+				total += weights[code]
+			}
+			return d.impurity(rows, MAE), total
+		}, order
+	}
+
+	return func(codes []int) (float64, float64) {
+		var total, sum, sumSquares float64
+		for _, code := range codes {
+			total += weights[code]
+			sum += sums[code]
+			sumSquares += squares[code]
+		}
+		if total == 0 {
+			return 0, 0
+		}
+		mean := sum / total
+		return math.Max(sumSquares/total-mean*mean, 0), total
+	}, order
+}
+
+// impurity returns the spread of the target values of the given rows by the
+// criterion, which is Variance when empty.
+func (d *Dataset) impurity(rows []int, criterion Criterion) float64 {
+	total, center := d.mean(rows)
+	if total == 0 {
+		return 0
+	}
+	if criterion == MAE {
+		center = d.median(rows)
+	}
+	var spread float64
+	for _, row := range rows {
+		deviation := d.values[row] - center
+		if criterion == MAE {
+			spread += d.weights[row] * math.Abs(deviation)
+		} else {
+			spread += d.weights[row] * deviation * deviation
+		}
+	}
+
+	return spread / total
+}
+
+// mean returns the total weight of the given rows and the weighted mean of their
+// target values.
+func (d *Dataset) mean(rows []int) (total, mean float64) {
+	var sum float64
+	for _, row := range rows {
+		total += d.weights[row]
+		sum += d.weights[row] * d.values[row]
+	}
+	if total == 0 {
+		return 0, 0
+	}
+
+	return total, sum / total
+}
+
+// median returns the weighted median of the target values of the given rows, the
+// midpoint of the two middle values when they divide the weight exactly in half.
+func (d *Dataset) median(rows []int) float64 {
+	sorted := append([]int(nil), rows...)
+	sort.Slice(sorted, func(i, j int) bool { return d.values[sorted[i]] < d.values[sorted[j]] })
+	var total float64
+	for _, row := range sorted {
+		total += d.weights[row]
+	}
+	var cumulative float64
+	for i, row := range sorted {
+		cumulative += d.weights[row]
+		if cumulative > total/2 {
+			return d.values[row]
+		}
+		if cumulative == total/2 && i+1 < len(sorted) {
+			return (d.values[row] + d.values[sorted[i+1]]) / 2
+		}
+	}
+
+	return 0
+}
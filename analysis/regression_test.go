@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"context"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"strings"
+	"testing"
+)
+
+const regressionData = `1
+hours,real
+2
+season,3,summer,winter,spring
+staff,2,few,many
+8
+summer,few,10
+summer,few,12
+summer,many,11
+summer,many,11
+winter,few,2
+winter,few,3
+winter,many,2
+winter,many,9
+`
+
+func TestBuildTreeContext_Regression(t *testing.T) {
+	quietly(t)
+	sample, err := parse.Parse(strings.NewReader(regressionData))
+	if err != nil {
+		t.Fatalf("parsing regression sample: %s", err.Error())
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	// season explains more of the variance than staff, despite the outlier of 9 hours
+	if tree.Label != "season" || tree.Summary == nil || tree.Summary.Count != 8 || tree.Summary.Mean != 7.5 {
+		t.Fatalf("expected a split on season over 8 examples with mean 7.5, got %s with %+v", tree.Label, tree.Summary)
+	}
+	summer, winter, spring := tree.Children[0], tree.Children[1], tree.Children[2]
+	if summer.Label != "staff" || summer.Children[1].Label != "11" {
+		t.Errorf("expected summer to split on staff with many predicting 11, got %s", summer.Label)
+	}
+	if !spring.Terminal || spring.Summary.Count != 0 || spring.Label != "7.5" {
+		t.Errorf("expected spring to predict the root mean without examples, got %s with %+v", spring.Label, spring.Summary)
+	}
+	prediction, err := tree.Predict(map[string]string{"season": "winter", "staff": "many"})
+	if err != nil || prediction != 5.5 {
+		t.Errorf("expected 5.5 hours for many winter staff, got %v with %v", prediction, err)
+	}
+	if winter.Summary.Median != 2.5 {
+		t.Errorf("expected a winter median of 2.5, got %v", winter.Summary.Median)
+	}
+
+	// spring predicts the median of every example rather than their mean
+	tree, err = BuildTreeContext(context.Background(), sample, Options{Criterion: MAE})
+	if err != nil {
+		t.Fatalf("building MAE tree: %s", err.Error())
+	}
+	prediction, err = tree.Predict(map[string]string{"season": "spring", "staff": "few"})
+	if err != nil || prediction != 9.5 {
+		t.Errorf("expected 9.5 hours for spring, got %v with %v", prediction, err)
+	}
+
+	for _, opts := range []Options{{Criterion: "median"}, {BalancedClassWeights: true}, {Costs: NewCostMatrix(parse.Targets{"a"})}} {
+		if _, err = BuildTreeContext(context.Background(), sample, opts); err == nil {
+			t.Errorf("expected an error building a regression tree with %+v", opts)
+		}
+	}
+}
+
+func TestDataset_Median(t *testing.T) {
+	d := &Dataset{RealTarget: "y", values: []float64{5, 1, 3, 2}, weights: []float64{1, 1, 1, 1}}
+	if m := d.median(d.allRows()); m != 2.5 {
+		t.Errorf("expected the midpoint 2.5, got %v", m)
+	}
+	d.weights[0] = 4
+	if m := d.median(d.allRows()); m != 5 {
+		t.Errorf("expected the heavy value 5, got %v", m)
+	}
+	if spread := d.impurity([]int{1, 2}, Variance); spread != 1 {
+		t.Errorf("expected a variance of 1, got %v", spread)
+	}
+}
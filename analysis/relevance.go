@@ -0,0 +1,289 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Flag marks an attribute that is unlikely to help a tree.
+type Flag string
+
+const (
+	// Constant marks an attribute with the same value in every example.
+	Constant Flag = "constant"
+	// NearConstant marks an attribute whose most common value covers at least
+	// RelevanceOptions.NearConstant of the example weight.
+	NearConstant Flag = "near-constant"
+	// IDLike marks a nominal attribute with about as many distinct values as there
+	// are examples, which splits perfectly in training and says nothing of new data.
+	IDLike Flag = "id-like"
+)
+
+// RelevanceOptions sets the thresholds at which attributes are flagged.
+type RelevanceOptions struct {
+	// NearConstant is the smallest share of the example weight the most common value
+	// must have for the attribute to be flagged near-constant, 0.95 when zero.
+	NearConstant float64
+	// IDLike is the smallest ratio of distinct values to examples for a nominal
+	// attribute to be flagged ID-like, 0.9 when zero.
+	IDLike float64
+}
+
+// Relevance describes how each attribute of a sample relates to the target and to
+// the other attributes, for understanding a dataset before building a tree from it.
+type Relevance struct {
+	// Entropy is the entropy of the targets, or the variance of a real target, at the root
+	Entropy    float64
+	Attributes []AttributeRelevance
+	// MutualInformation holds the mutual information in bits between each pair of
+	// Attributes, indexed as Attributes is. Pairs with a real attribute are zero.
+	MutualInformation [][]float64
+	// CramersV holds Cramér's V between each pair of Attributes, from zero when the
+	// attributes are independent to one when either determines the other.
+	CramersV [][]float64
+}
+
+// AttributeRelevance describes one attribute of a sample.
+type AttributeRelevance struct {
+	Name string
+	Real bool `json:",omitempty"`
+	// Gain and GainRatio are those of splitting the root on the attribute, as ID3
+	// computes them. They are zero for real attributes, which trees do not split on.
+	Gain      float64
+	GainRatio float64
+	// Distinct is the number of different values the examples have
+	Distinct int
+	// TopShare is the share of the example weight having the most common value
+	TopShare float64
+	Flags    []Flag `json:",omitempty"`
+}
+
+// NewRelevance measures the relevance of every attribute of the sample.
+func NewRelevance(sample parse.Sample, opts RelevanceOptions) (*Relevance, error) {
+	if opts.NearConstant < 0 || opts.NearConstant > 1 || opts.IDLike < 0 || opts.IDLike > 1 {
+		return nil, fmt.Errorf("thresholds %v and %v must be between zero and one", opts.NearConstant, opts.IDLike)
+	}
+	if opts.NearConstant == 0 {
+		opts.NearConstant = 0.95
+	}
+	if opts.IDLike == 0 {
+		opts.IDLike = 0.9
+	}
+	if len(sample.Examples) == 0 {
+		return nil, fmt.Errorf("sample has no examples")
+	}
+	root, err := NewSample(sample)
+	if err != nil {
+		return nil, err
+	}
+	rootTypes := make(map[string]AttributeType)
+	for _, at := range root.AttributeTypes {
+		rootTypes[at.Name] = at
+	}
+	values := make([]map[string]string, len(sample.Examples))
+	for i, eg := range sample.Examples {
+		if values[i], err = sample.AttributeTypes.ExampleValues(eg); err != nil {
+			return nil, fmt.Errorf("reading example %d: %w", i, err)
+		}
+	}
+
+	r := &Relevance{Entropy: root.Entropy}
+	for _, at := range sample.AttributeTypes {
+		a := AttributeRelevance{Name: at.Name, Real: at.Real}
+		if rootType, ok := rootTypes[at.Name]; ok {
+			a.Gain = rootType.Gain
+			a.GainRatio = gainRatio(rootType.Gain, rootType.Values...)
+		}
+		counts := make(map[string]float64)
+		var total float64
+		for i, eg := range sample.Examples {
+			counts[values[i][at.Name]] += eg.EffectiveWeight()
+			total += eg.EffectiveWeight()
+		}
+		a.Distinct = len(counts)
+		for _, count := range counts {
+			a.TopShare = math.Max(a.TopShare, count/total)
+		}
+		switch {
+		case a.Distinct == 1:
+			a.Flags = append(a.Flags, Constant)
+		case a.TopShare >= opts.NearConstant:
+			a.Flags = append(a.Flags, NearConstant)
+		}
+		if !at.Real && a.Distinct > 1 && float64(a.Distinct) >= opts.IDLike*float64(len(sample.Examples)) {
+			a.Flags = append(a.Flags, IDLike)
+		}
+		r.Attributes = append(r.Attributes, a)
+	}
+
+	n := len(sample.AttributeTypes)
+	r.MutualInformation, r.CramersV = make([][]float64, n), make([][]float64, n)
+	for i := range r.MutualInformation {
+		r.MutualInformation[i], r.CramersV[i] = make([]float64, n), make([]float64, n)
+	}
+	for i, a := range sample.AttributeTypes {
+		for j := i; j < n; j++ {
+			b := sample.AttributeTypes[j]
+			if a.Real || b.Real {
+				continue
+			}
+			table := newContingency(sample, values, a.Name, b.Name)
+			r.MutualInformation[i][j] = table.mutualInformation()
+			r.CramersV[i][j] = table.cramersV()
+			r.MutualInformation[j][i], r.CramersV[j][i] = r.MutualInformation[i][j], r.CramersV[i][j]
+		}
+	}
+
+	return r, nil
+}
+
+// contingency holds the total weight of the examples having each pair of values of
+// two attributes.
+type contingency struct {
+	counts     map[[2]string]float64
+	rows, cols map[string]float64
+	total      float64
+}
+
+func newContingency(sample parse.Sample, values []map[string]string, a, b string) contingency {
+	c := contingency{
+		counts: make(map[[2]string]float64),
+		rows:   make(map[string]float64),
+		cols:   make(map[string]float64),
+	}
+	for i, eg := range sample.Examples {
+		va, vb := values[i][a], values[i][b]
+		w := eg.EffectiveWeight()
+		c.counts[[2]string{va, vb}] += w
+		c.rows[va] += w
+		c.cols[vb] += w
+		c.total += w
+	}
+
+	return c
+}
+
+// mutualInformation returns the information in bits that either attribute's value
+// gives about the other's.
+func (c contingency) mutualInformation() float64 {
+	var mi float64
+	for _, row := range sortedKeys(c.rows) {
+		for _, col := range sortedKeys(c.cols) {
+			if count := c.counts[[2]string{row, col}]; count > 0 {
+				mi += count / c.total * math.Log2(count*c.total/(c.rows[row]*c.cols[col]))
+			}
+		}
+	}
+
+	// rounding can leave independent attributes a hair below zero
+	return math.Max(mi, 0)
+}
+
+// cramersV returns the chi-squared statistic of the table scaled to lie between
+// zero and one. Attributes with a single value present have no association.
+func (c contingency) cramersV() float64 {
+	k := math.Min(float64(len(c.rows)), float64(len(c.cols))) - 1
+	if k < 1 {
+		return 0
+	}
+	var chiSquared float64
+	for _, row := range sortedKeys(c.rows) {
+		for _, col := range sortedKeys(c.cols) {
+			expected := c.rows[row] * c.cols[col] / c.total
+			diff := c.counts[[2]string{row, col}] - expected
+			chiSquared += diff * diff / expected
+		}
+	}
+
+	return math.Sqrt(chiSquared / (c.total * k))
+}
+
+// sortedKeys returns the values of the map in order, so that sums over them do not
+// depend on map order.
+func sortedKeys(totals map[string]float64) []string {
+	keys := make([]string, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Ranked returns the attributes by descending gain ratio, with attributes of equal
+// gain ratio in declared order.
+func (r *Relevance) Ranked() []AttributeRelevance {
+	ranked := make([]AttributeRelevance, len(r.Attributes))
+	copy(ranked, r.Attributes)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].GainRatio > ranked[j].GainRatio
+	})
+
+	return ranked
+}
+
+// Redundant returns the pairs of nominal attributes whose Cramér's V is at least
+// threshold, most associated first.
+func (r *Relevance) Redundant(threshold float64) [][2]string {
+	type pair struct {
+		names [2]string
+		v     float64
+	}
+	var pairs []pair
+	for i := range r.Attributes {
+		for j := i + 1; j < len(r.Attributes); j++ {
+			if r.CramersV[i][j] >= threshold {
+				pairs = append(pairs, pair{[2]string{r.Attributes[i].Name, r.Attributes[j].Name}, r.CramersV[i][j]})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].v > pairs[j].v })
+	names := make([][2]string, len(pairs))
+	for i, p := range pairs {
+		names[i] = p.names
+	}
+
+	return names
+}
+
+// String renders the attributes ranked by gain ratio with their flags, followed by
+// the matrix of Cramér's V between attributes.
+func (r *Relevance) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "root entropy: %.4f\n", r.Entropy)
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "attribute\tgain\tgain ratio\tdistinct\ttop share\tflags\t\n")
+	for _, a := range r.Ranked() {
+		flags := make([]string, len(a.Flags))
+		for i, f := range a.Flags {
+			flags[i] = string(f)
+		}
+		if a.Real {
+			flags = append([]string{"real"}, flags...)
+		}
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%d\t%.3f\t%s\t\n",
+			a.Name, a.Gain, a.GainRatio, a.Distinct, a.TopShare, strings.Join(flags, ", "))
+	}
+	tw.Flush()
+	sb.WriteString("\ncramér's v\n")
+	tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\t")
+	for _, a := range r.Attributes {
+		fmt.Fprintf(tw, "%s\t", a.Name)
+	}
+	fmt.Fprintln(tw)
+	for i, a := range r.Attributes {
+		fmt.Fprintf(tw, "%s\t", a.Name)
+		for j := range r.Attributes {
+			fmt.Fprintf(tw, "%.3f\t", r.CramersV[i][j])
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	return sb.String()
+}
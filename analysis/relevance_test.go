@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const relevanceData = `2
+yes,no
+5
+id,4,a,b,c,d
+colour,2,red,blue
+shade,2,dark,light
+const,2,x,y
+mostly,2,p,q
+4
+a,red,dark,x,p,yes
+b,red,dark,x,p,yes
+c,blue,light,x,p,no
+d,blue,light,x,q,no
+`
+
+func TestNewRelevance(t *testing.T) {
+	sample, err := parse.Parse(strings.NewReader(relevanceData))
+	if err != nil {
+		t.Fatalf("parsing relevance sample: %v", err)
+	}
+	r, err := NewRelevance(sample, RelevanceOptions{NearConstant: 0.75})
+	if err != nil {
+		t.Fatalf("measuring relevance: %v", err)
+	}
+	flags := make(map[string][]Flag)
+	for _, a := range r.Attributes {
+		flags[a.Name] = a.Flags
+	}
+	want := map[string][]Flag{
+		"id":     {IDLike},
+		"colour": nil,
+		"shade":  nil,
+		"const":  {Constant},
+		"mostly": {NearConstant},
+	}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("expected flags %v, got %v", want, flags)
+	}
+	// colour separates the targets with a single binary split, which id needs four branches for
+	if ranked := r.Ranked(); ranked[0].Name != "colour" || ranked[0].GainRatio != 1 {
+		t.Errorf("expected colour to rank first with gain ratio 1, got %v", ranked[0])
+	}
+	// shade is colour renamed, so each tells everything about the other
+	if math.Abs(r.MutualInformation[1][2]-1) > 1e-9 || math.Abs(r.CramersV[1][2]-1) > 1e-9 {
+		t.Errorf("expected colour and shade to share 1 bit with a V of 1, got %v and %v", r.MutualInformation[1][2], r.CramersV[1][2])
+	}
+	if r.CramersV[1][3] != 0 {
+		t.Errorf("expected no association with a constant, got %v", r.CramersV[1][3])
+	}
+	// id determines every attribute that has more than one value
+	pairs := [][2]string{{"id", "colour"}, {"id", "shade"}, {"id", "mostly"}, {"colour", "shade"}}
+	if redundant := r.Redundant(0.9); !reflect.DeepEqual(redundant, pairs) {
+		t.Errorf("expected pairs %v to be redundant, got %v", pairs, redundant)
+	}
+	if _, err = NewRelevance(sample, RelevanceOptions{IDLike: 2}); err == nil {
+		t.Error("expected an error for a threshold above one")
+	}
+}
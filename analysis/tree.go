@@ -1,47 +1,43 @@
 package analysis
 
 import (
+	"context"
 	"fmt"
 	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+	"os"
+	"sort"
+	"strings"
 )
 
+// Output receives the progress messages written while building a tree.
+var Output io.Writer = os.Stdout
+
+// BuildTree builds a tree serially with no budgets, equivalent to calling
+// BuildTreeContext with a background context and zero Options.
 func BuildTree(sample parse.Sample) (Node, error) {
-	newSample, err := NewSample(sample)
-	if err != nil {
-		return Node{}, fmt.Errorf("building analyzed sample from parse sample")
-	}
-	rootNode, err := build(newSample, "", nil)
-	if err != nil {
-		return rootNode, fmt.Errorf("building root node: %w", err)
-	}
-	return rootNode, nil
+	return BuildTreeContext(context.Background(), sample, Options{})
 }
 
-func build(sample Sample, filterValue string, parent *Node) (Node, error) {
+// build creates the node for the sample s, which holds the examples of the parent's
+// sample that have filterValue for the parent's best gain attribute.
+func (b *builder) build(s Sample, filterValue string, parent *Node) (Node, error) {
 	if parent == nil {
-		fmt.Println("starting first node")
+		fmt.Fprintln(Output, "starting first node")
 	} else {
-		fmt.Println("starting node", filterValue, parent.Sample.BestGainAttribute.Name)
+		fmt.Fprintln(Output, "starting node", filterValue, parent.Sample.BestGainAttribute.Name)
 	}
-	var s Sample
-	if filterValue != "" {
-		if parent == nil {
-			return Node{}, fmt.Errorf("parent cannot be nil when a filter value is given")
-		}
-		// data passed in before filtering
-		unfilteredSampleData := sample.data
-		// data filtered by parent's best gain attribute and the filter value for this child node
-		filteredData, err := unfilteredSampleData.Filter(parent.Sample.BestGainAttribute.Name, filterValue)
-		if err != nil {
-			return Node{}, fmt.Errorf("filtering data from passed in sample: %w", err)
-		}
-		s, err = NewSample(filteredData)
-		if err != nil {
-			return Node{}, fmt.Errorf("creating a new analysis sample from filtered data: %w", err)
-		}
-	} else {
-		// if not filtering the data
-		s = sample
+	if filterValue != "" && parent == nil {
+		return Node{}, fmt.Errorf("parent cannot be nil when a filter value is given")
+	}
+	if err := b.admit(s); err != nil {
+		return Node{}, err
+	}
+	exhausted := b.exhausted(s, parent)
+	// a regression tree ends where a classification tree would in the cases below,
+	// but predicts a value rather than a target
+	if s.dataset.regression() && (len(s.rows) == 0 || exhausted || s.pure()) {
+		return b.regressionLeaf(s, filterValue, parent)
 	}
 
 	/*
@@ -50,32 +46,34 @@ func build(sample Sample, filterValue string, parent *Node) (Node, error) {
 
 	// 1) Every element in the subset belongs to the same class;
 	// in which case the node is turned into a leaf node and labelled with the class of the examples.
-	if len(s.Targets) == 1 && len(s.data.Examples) > 0 {
+	if len(s.Targets) == 1 && len(s.rows) > 0 {
 		node := Node{
-			parent:      parent,
-			Children:    nil,
-			Sample:      s,
-			FilterValue: filterValue,
-			Label:       s.Targets[0],
-			Terminal:    true,
-		}
-		fmt.Println("completed node", node.FilterValue, node.Label)
+			parent:       parent,
+			Children:     nil,
+			Sample:       s,
+			FilterValue:  filterValue,
+			TargetCounts: s.TargetCounts(),
+			Terminal:     true,
+		}
+		node.Label = b.leafLabel(node.TargetCounts, s.Targets[0])
+		fmt.Fprintln(Output, "completed node", node.FilterValue, node.Label)
 		return node, nil
 	}
 	// 2) There are no more attributes to be selected, but the examples still do not belong to the same
 	// class. In this case, the node is made a leaf node and labelled with the most common class of
 	// the examples in the subset.
-	if len(s.AttributeTypes) == 0 && len(s.data.Examples) > 0 {
+	if exhausted && len(s.rows) > 0 {
 		node := Node{
-			parent:      parent,
-			Children:    nil,
-			Sample:      s,
-			FilterValue: filterValue,
-			Terminal:    true,
+			parent:       parent,
+			Children:     nil,
+			Sample:       s,
+			FilterValue:  filterValue,
+			TargetCounts: s.TargetCounts(),
+			Terminal:     true,
 		}
-		node.Label = node.mostCommonTarget()
+		node.Label = b.leafLabel(node.TargetCounts, node.mostCommonTarget())
 
-		fmt.Println("completed node", node.FilterValue, node.Label)
+		fmt.Fprintln(Output, "completed node", node.FilterValue, node.Label)
 		return node, nil
 	}
 
@@ -83,67 +81,85 @@ func build(sample Sample, filterValue string, parent *Node) (Node, error) {
 	// to match a specific value of the selected attribute. An example could be the absence of a person
 	// among the population with age over 100 years. Then a leaf node is created and labelled with the
 	// most common class of the examples in the parent node's set.
-	if len(s.data.Examples) == 0 {
+	if len(s.rows) == 0 {
 		if parent == nil {
 			return Node{}, fmt.Errorf("parent cannot be nil when there are no remaining examples")
 		}
 		node := Node{
-			parent:      parent,
-			Children:    nil,
-			Sample:      s,
-			FilterValue: "",
-			Label:       parent.mostCommonTarget(),
-			Terminal:    true,
-		}
-		fmt.Println("completed node", node.FilterValue, node.Label)
+			parent:       parent,
+			Children:     nil,
+			Sample:       s,
+			FilterValue:  filterValue,
+			TargetCounts: s.TargetCounts(),
+			Terminal:     true,
+		}
+		node.Label = b.leafLabel(parent.TargetCounts, parent.mostCommonTarget())
+		fmt.Fprintln(Output, "completed node", node.FilterValue, node.Label)
 		return node, nil
 	}
 
 	bestGainAttribute := s.BestGainAttribute
 	if bestGainAttribute.Name != "" {
-		fmt.Printf("\tbest gain attribute '%s' has %d values\n", bestGainAttribute.Name, len(bestGainAttribute.Values))
+		fmt.Fprintf(Output, "\tbest gain attribute '%s' has %d values\n", bestGainAttribute.Name, len(bestGainAttribute.Values))
 	}
 	node := Node{
-		parent:      parent,
-		Sample:      s,
-		FilterValue: filterValue,
-		Label:       bestGainAttribute.Name,
-		Terminal:    false,
-	}
-
-	var children []Node
-	for _, value := range bestGainAttribute.Values {
-		fmt.Println("\texamining value", value.Value, "of", bestGainAttribute.Name)
-		child, err := build(s, value.Value, &node)
-		if err != nil {
-			var label string
-			if parent == nil {
-				label = "root"
-			} else {
-				label = parent.Label
-			}
-			return Node{}, fmt.Errorf("building child node of %s: %w", label, err)
+		parent:       parent,
+		Sample:       s,
+		FilterValue:  filterValue,
+		Label:        bestGainAttribute.Name,
+		TargetCounts: s.TargetCounts(),
+		Terminal:     false,
+	}
+	if s.dataset.regression() {
+		node.Summary = s.summary()
+	}
+
+	children, err := b.buildChildren(s.split(), &node)
+	if err != nil {
+		var label string
+		if parent == nil {
+			label = "root"
+		} else {
+			label = parent.Label
 		}
-		children = append(children, child)
+		return Node{}, fmt.Errorf("building child node of %s: %w", label, err)
 	}
 	node.Children = children
 
-	fmt.Println("completed node", node.FilterValue, node.Label)
+	fmt.Fprintln(Output, "completed node", node.FilterValue, node.Label)
 	return node, nil
 }
 
+// exhausted reports whether the sample, as a child of parent, has no attributes left
+// to split on. A node at the maximum depth is treated as having none, as is one where
+// rounding left every gain slightly below zero, so that no attribute was chosen.
+func (b *builder) exhausted(s Sample, parent *Node) bool {
+	return len(s.AttributeTypes) == 0 || s.BestGainAttribute.Name == "" ||
+		b.opts.MaxDepth > 0 && parent.depth()+1 >= b.opts.MaxDepth
+}
+
 type AttributeType struct {
 	Name   string
 	Gain   float64
 	Values AttributeValues
+	// Cut is the number of leading values of an ordinal attribute in the first of
+	// the two ranges it splits into, or zero when it splits into one branch per
+	// value. Values then holds the two ranges.
+	Cut int `json:",omitempty"`
+	// first marks the value codes in the first of two branches when the attribute
+	// splits its values in two, and is nil when it splits into one branch per value
+	first []bool
+	// index is the position of the attribute in the dataset
+	index int
 }
 
 type AttributeTypes []AttributeType
 
 type AttributeValue struct {
-	Value       string
-	Entropy     float64
-	Occurrences int
+	Value   string
+	Entropy float64
+	// Occurrences is the total weight of the examples having the value
+	Occurrences float64
 }
 
 type AttributeValues []AttributeValue
@@ -153,8 +169,21 @@ type Node struct {
 	Children    []Node
 	Sample      Sample
 	FilterValue string
-	Label       string
-	Terminal    bool
+	// FilterOp is "<=" or ">" when the node holds the examples whose value of an
+	// ordinal attribute is ordered at or before, or after, FilterValue, and "in"
+	// when it holds the examples having any of FilterValues, which FilterValue then
+	// lists. It is empty when the node holds the examples having FilterValue.
+	FilterOp string `json:",omitempty"`
+	// FilterValues are the values that FilterOp admits, in declared order
+	FilterValues []string `json:",omitempty"`
+	Label        string
+	// TargetCounts is the total weight of the training examples of each target that reached this node
+	TargetCounts map[string]float64
+	// Summary describes the real target values of the training examples that
+	// reached a node of a regression tree, whose leaves are labelled with the value
+	// they predict
+	Summary  *Summary `json:",omitempty"`
+	Terminal bool
 }
 
 type Root Node
@@ -174,6 +203,16 @@ func countNodes(currentNode *Node, runningTotal *int) int {
 	return *runningTotal
 }
 
+// depth returns the number of ancestors of the node, or -1 for a nil node.
+func (n *Node) depth() int {
+	depth := -1
+	for ; n != nil; n = n.parent {
+		depth++
+	}
+
+	return depth
+}
+
 func (n Node) Root() Root {
 	node := n
 	for {
@@ -184,78 +223,411 @@ func (n Node) Root() Root {
 	}
 }
 
+// mostCommonTarget returns the target with the greatest weight of examples in the
+// node's sample, preferring the target declared first on ties.
 func (n Node) mostCommonTarget() string {
-	targetOccurrences := make(map[string]int)
-	for _, eg := range n.Sample.data.Examples {
-		t := eg.Target
-		targetOccurrences[t] += 1
+	s := n.Sample
+	if s.dataset == nil {
+		return ""
 	}
 	var highestName string
-	var highestCount int
-	for name, occurrences := range targetOccurrences {
+	var highestCount float64
+	for code, occurrences := range s.classCounts {
 		if occurrences > highestCount {
-			highestName, highestCount = name, occurrences
+			highestName, highestCount = s.dataset.Targets[code], occurrences
 		}
 	}
 
 	return highestName
 }
 
+// Sample is the subset of a Dataset that reaches a node, along with the statistics
+// used to choose how the node splits.
 type Sample struct {
-	Targets           Targets
+	Targets Targets
+	// Entropy is the entropy of the targets, or the impurity of a real target by
+	// the build's Criterion
 	Entropy           float64
 	AttributeTypes    AttributeTypes
 	BestGainAttribute AttributeType
-	data              parse.Sample
+	dataset           *Dataset
+	// rows are the dataset rows in this sample
+	rows []int
+	// attributes are the dataset indexes of the attributes that remain available for splitting
+	attributes []int
+	splitting  splitOptions
+	// classCounts is the total weight of the rows having each target code
+	classCounts []float64
+	// valueCounts holds the total weight of the rows having each value code and
+	// target code of each remaining attribute, in the order of attributes. It is
+	// kept only when examples may be added later, and is nil for a regression sample.
+	valueCounts [][][]float64
+}
+
+// splitOptions are the build options that decide how each node's sample splits
+type splitOptions struct {
+	// binarySplits allows nominal attributes to split their values into two sets
+	binarySplits bool
+	// criterion measures the impurity of a real target
+	criterion Criterion
+	// incremental keeps the counts of each sample so that rows can be added to it
+	incremental bool
 }
 
 func NewSample(sample parse.Sample) (Sample, error) {
-	targetTotals := make([]int, sample.NumTargets)
-	for _, eg := range sample.Examples {
-		i, err := sample.Targets.Index(eg.Target)
-		if err != nil {
-			return Sample{}, fmt.Errorf("finding targets for calculating totals: %w", err)
+	dataset, err := NewDataset(sample)
+	if err != nil {
+		return Sample{}, fmt.Errorf("encoding dataset for new sample: %w", err)
+	}
+
+	return newSubset(dataset, dataset.allRows(), dataset.nominalAttributes(), splitOptions{}), nil
+}
+
+// newSubset computes the statistics of the given rows and attributes of the dataset.
+func newSubset(dataset *Dataset, rows []int, attributes []int, splitting splitOptions) Sample {
+	s := Sample{
+		dataset:     dataset,
+		rows:        rows,
+		attributes:  attributes,
+		splitting:   splitting,
+		classCounts: dataset.targetCounts(rows),
+	}
+	if splitting.incremental && !dataset.regression() {
+		s.valueCounts = make([][][]float64, len(attributes))
+		for i, attribute := range attributes {
+			s.valueCounts[i] = dataset.valueTargetCounts(attribute, rows)
 		}
-		targetTotals[i] = targetTotals[i] + 1
 	}
-	entropySet := entropy(targetTotals)
-	attributeTypes, err := getAttributeTypes(sample, entropySet)
-	if err != nil {
-		return Sample{}, fmt.Errorf("getting analysis attribute types of new sample: %w", err)
+	s.updateStatistics()
+
+	return s
+}
+
+// updateStatistics computes the entropy of the sample and the statistics of its
+// attributes.
+func (s *Sample) updateStatistics() {
+	// entropy is calculated over the targets present in the subset only
+	s.Targets = nil
+	var presentTargetCounts []float64
+	for code, count := range s.classCounts {
+		if count > 0 {
+			s.Targets = append(s.Targets, s.dataset.Targets[code])
+			presentTargetCounts = append(presentTargetCounts, count)
+		}
+	}
+	s.Entropy = entropy(presentTargetCounts)
+	if s.dataset.regression() {
+		// the spread of a real target stands in for entropy
+		s.Entropy = s.dataset.impurity(s.rows, s.splitting.criterion)
+	}
+	if len(s.rows) == 0 {
+		return
+	}
+	s.AttributeTypes = s.getAttributeTypes()
+	s.BestGainAttribute = getBestGainAttribute(s.AttributeTypes)
+}
+
+// valueTargetCounts returns the total weight of the sample's rows having each value
+// code and target code of its i-th remaining attribute.
+func (s Sample) valueTargetCounts(i int) [][]float64 {
+	if s.valueCounts != nil {
+		return s.valueCounts[i]
+	}
+
+	return s.dataset.valueTargetCounts(s.attributes[i], s.rows)
+}
+
+// split partitions the sample by the values of its best gain attribute, returning
+// one subset per value in the order the values are declared, or two subsets when
+// the attribute splits its values in two.
+func (s Sample) split() []Sample {
+	attribute := s.BestGainAttribute.index
+	if first := s.BestGainAttribute.first; first != nil {
+		// the attribute stays available to divide each part further
+		parts := s.dataset.partitionBinary(attribute, s.rows, first)
+		return []Sample{
+			newSubset(s.dataset, parts[0], s.attributes, s.splitting),
+			newSubset(s.dataset, parts[1], s.attributes, s.splitting),
+		}
 	}
-	newSample := Sample{
-		Targets:           []string(sample.Targets),
-		Entropy:           entropySet,
-		AttributeTypes:    attributeTypes,
-		BestGainAttribute: getBestGainAttribute(attributeTypes),
-		data:              sample,
+
+	return s.splitBy(attribute)
+}
+
+// splitBy partitions the sample into one subset per value of the attribute at the
+// given dataset index, which is no longer available to the subsets, whether or not
+// it is the sample's best gain attribute.
+func (s Sample) splitBy(attribute int) []Sample {
+	remaining := make([]int, 0, len(s.attributes)-1)
+	for _, a := range s.attributes {
+		if a != attribute {
+			remaining = append(remaining, a)
+		}
+	}
+	parts := s.dataset.partition(attribute, s.rows)
+	subsets := make([]Sample, len(parts))
+	for i, rows := range parts {
+		subsets[i] = newSubset(s.dataset, rows, remaining, s.splitting)
 	}
 
-	return newSample, nil
+	return subsets
 }
 
-func getAttributeTypes(sample parse.Sample, entropySet float64) (AttributeTypes, error) {
-	attributeTypes := make(AttributeTypes, sample.NumAttributes)
-	for iAV, at := range sample.AttributeTypes {
-		attributeOccurrenceLookup, err := at.OccurrencesInTargets(sample)
-		if err != nil {
-			return attributeTypes, fmt.Errorf("getting attribute type occurrences in targets for %s: %w", at.Name, err)
+// TargetCounts returns the total weight of the examples of each target in the sample
+func (s Sample) TargetCounts() map[string]float64 {
+	counts := make(map[string]float64)
+	if s.dataset == nil {
+		return counts
+	}
+	for code, count := range s.classCounts {
+		if count > 0 {
+			counts[s.dataset.Targets[code]] = count
 		}
-		attrValues := make(AttributeValues, at.NumValues)
+	}
+
+	return counts
+}
+
+// measure returns the impurity of the examples of a sample having any of the given
+// value codes of an attribute, along with their total weight. Impurity is the
+// entropy of the targets, or the spread of a real target by the sample's Criterion.
+type measure func(codes []int) (impurity, total float64)
+
+func (s Sample) getAttributeTypes() AttributeTypes {
+	// value entropy considers only the targets present in the sample
+	var presentTargets []int
+	for code, count := range s.classCounts {
+		if count > 0 {
+			presentTargets = append(presentTargets, code)
+		}
+	}
+	attributeTypes := make(AttributeTypes, len(s.attributes))
+	for iAV, attribute := range s.attributes {
+		at := s.dataset.AttributeTypes[attribute]
+		var m measure
+		var order func(code int) float64
+		if s.dataset.regression() {
+			m, order = s.regressionMeasure(attribute)
+		} else {
+			m, order = entropyMeasure(s.valueTargetCounts(iAV), presentTargets)
+		}
+		attrValues := make(AttributeValues, len(at.Values))
 		for iVal, v := range at.Values {
-			targetOccurrences := attributeOccurrenceLookup[v]
+			impurity, total := m([]int{iVal})
 			attrValues[iVal] = AttributeValue{
 				Value:       v,
-				Entropy:     entropy(targetOccurrences),
-				Occurrences: attributeOccurrenceLookup.AttributeValueTotal(v),
+				Entropy:     impurity,
+				Occurrences: total,
 			}
 		}
 		attributeTypes[iAV].Name = at.Name
 		attributeTypes[iAV].Values = attrValues
-		attributeTypes[iAV].Gain = gain(entropySet, attrValues...)
+		attributeTypes[iAV].Gain = gain(s.Entropy, attrValues...)
+		attributeTypes[iAV].index = attribute
+		switch {
+		case at.Ordinal && len(at.Values) > 2:
+			s.cutOrdinal(&attributeTypes[iAV], at.Values, m)
+		case s.splitting.binarySplits && len(at.Values) > 2:
+			s.partitionNominal(&attributeTypes[iAV], at.Values, m, order)
+		}
+	}
+
+	return attributeTypes
+}
+
+// entropyMeasure measures the entropy of the present targets among the examples
+// having an attribute's values, given the weight of each value's examples of each
+// target. For two targets, it also orders the values by the proportion of the first
+// target, and otherwise returns no order.
+func entropyMeasure(valueTargetCounts [][]float64, presentTargets []int) (measure, func(code int) float64) {
+	m := func(codes []int) (float64, float64) {
+		occurrences := make([]float64, len(presentTargets))
+		var total float64
+		for i, target := range presentTargets {
+			for _, code := range codes {
+				occurrences[i] += valueTargetCounts[code][target]
+			}
+			total += occurrences[i]
+		}
+		return entropy(occurrences), total
+	}
+	if len(presentTargets) != 2 {
+		return m, nil
+	}
+
+	return m, func(code int) float64 {
+		counts := valueTargetCounts[code]
+		return counts[presentTargets[0]] / (counts[presentTargets[0]] + counts[presentTargets[1]])
+	}
+}
+
+// cutOrdinal replaces an ordinal attribute's split into one branch per value with
+// a split of its ordered values into two ranges when one has a higher gain ratio.
+// Gain alone cannot choose between them, since splitting by value refines every
+// cut and so never has less gain.
+func (s Sample) cutOrdinal(at *AttributeType, declared []string, m measure) {
+	for cut := 1; cut < len(declared); cut++ {
+		first := make([]bool, len(declared))
+		for code := 0; code < cut; code++ {
+			first[code] = true
+		}
+		if s.tryBinary(at, first, m, "<= "+declared[cut-1], "> "+declared[cut-1]) {
+			at.Cut = cut
+		}
+	}
+}
+
+// maxExactValues is the most values present in a sample for which every partition
+// of a nominal attribute's values in two is tried. There are 2^(n-1)-1 of them.
+const maxExactValues = 12
+
+// partitionNominal replaces a nominal attribute's split into one branch per value
+// with a split of its values into two sets when one has a higher gain ratio. Every
+// partition of the values present is tried when there are few enough of them.
+// Otherwise the values are sorted by the given order and only the cuts of that
+// order are tried. Ordering by the proportion of the first of two targets, or by
+// the mean of a real target under Variance, finds the best partition (Breiman et
+// al., 1984). Values absent from the sample join the set with more weight.
+func (s Sample) partitionNominal(at *AttributeType, declared []string, m measure, order func(code int) float64) {
+	totals := make([]float64, len(declared))
+	var present []int
+	for code := range declared {
+		totals[code] = at.Values[code].Occurrences
+		if totals[code] > 0 {
+			present = append(present, code)
+		}
+	}
+	if len(present) <= 2 {
+		return
+	}
+	var candidates [][]int
+	switch {
+	case len(present) <= maxExactValues:
+		// each partition is tried once, with the first present value in the first set
+		for mask := 0; mask < 1<<(len(present)-1)-1; mask++ {
+			candidate := []int{present[0]}
+			for i, code := range present[1:] {
+				if mask&(1<<i) != 0 {
+					candidate = append(candidate, code)
+				}
+			}
+			candidates = append(candidates, candidate)
+		}
+	case order != nil:
+		ordered := append([]int(nil), present...)
+		sort.SliceStable(ordered, func(i, j int) bool { return order(ordered[i]) < order(ordered[j]) })
+		for k := 1; k < len(ordered); k++ {
+			candidates = append(candidates, ordered[:k])
+		}
+	default:
+		return
+	}
+	for _, candidate := range candidates {
+		first := make([]bool, len(declared))
+		var firstTotal, allTotal float64
+		for _, code := range candidate {
+			first[code] = true
+			firstTotal += totals[code]
+		}
+		for _, total := range totals {
+			allTotal += total
+		}
+		if firstTotal > allTotal-firstTotal {
+			for _, code := range absent(totals) {
+				first[code] = true
+			}
+		}
+		var firstValues, secondValues []string
+		for code, v := range declared {
+			if first[code] {
+				firstValues = append(firstValues, v)
+			} else {
+				secondValues = append(secondValues, v)
+			}
+		}
+		s.tryBinary(at, first, m, strings.Join(firstValues, ", "), strings.Join(secondValues, ", "))
+	}
+}
+
+func absent(totals []float64) []int {
+	var codes []int
+	for code, total := range totals {
+		if total == 0 {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+// tryBinary splits the attribute's values into the two branches that first marks
+// when that has a higher gain ratio than the attribute's current split, naming the
+// branches' values with the given descriptions. It reports whether it did.
+func (s Sample) tryBinary(at *AttributeType, first []bool, m measure, firstName, secondName string) bool {
+	var firstCodes, secondCodes []int
+	for code, isFirst := range first {
+		if isFirst {
+			firstCodes = append(firstCodes, code)
+		} else {
+			secondCodes = append(secondCodes, code)
+		}
 	}
+	firstImpurity, firstTotal := m(firstCodes)
+	secondImpurity, secondTotal := m(secondCodes)
+	if firstTotal == 0 || secondTotal == 0 {
+		return false
+	}
+	parts := AttributeValues{
+		{Value: firstName, Entropy: firstImpurity, Occurrences: firstTotal},
+		{Value: secondName, Entropy: secondImpurity, Occurrences: secondTotal},
+	}
+	binaryGain := gain(s.Entropy, parts...)
+	if gainRatio(binaryGain, parts...) <= gainRatio(at.Gain, at.Values...) {
+		return false
+	}
+	at.Gain, at.Values, at.first = binaryGain, parts, first
+
+	return true
+}
+
+// branches returns the filter of each subset that split returns.
+func (s Sample) branches() []branch {
+	at := s.BestGainAttribute
+	if at.first == nil {
+		branches := make([]branch, len(at.Values))
+		for i, av := range at.Values {
+			branches[i] = branch{value: av.Value}
+		}
+		return branches
+	}
+	declared := s.dataset.AttributeTypes[at.index].Values
+	if at.Cut > 0 {
+		boundary := declared[at.Cut-1]
+		return []branch{
+			{value: boundary, op: "<=", values: declared[:at.Cut]},
+			{value: boundary, op: ">", values: declared[at.Cut:]},
+		}
+	}
+	var firstValues, secondValues []string
+	for code, v := range declared {
+		if at.first[code] {
+			firstValues = append(firstValues, v)
+		} else {
+			secondValues = append(secondValues, v)
+		}
+	}
+
+	return []branch{
+		{value: strings.Join(firstValues, ", "), op: "in", values: firstValues},
+		{value: strings.Join(secondValues, ", "), op: "in", values: secondValues},
+	}
+}
 
-	return attributeTypes, nil
+// branch describes which examples of its parent a child node holds
+type branch struct {
+	value  string
+	op     string
+	values []string
 }
 
 func getBestGainAttribute(attrTypes AttributeTypes) AttributeType {
@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// syntheticSample generates a deterministic sample with the given number of rows
+// and nominal attributes, where the target depends on the first three attributes
+// with some noise so that trees are several levels deep.
+func syntheticSample(rows, attributes int) parse.Sample {
+	rng := rand.New(rand.NewSource(1))
+	sample := parse.Sample{
+		NumTargets:    2,
+		Targets:       parse.Targets{"yes", "no"},
+		NumAttributes: attributes,
+		NumExamples:   rows,
+	}
+	for i := 0; i < attributes; i++ {
+		numValues := 2 + i%4
+		at := parse.AttributeType{Name: fmt.Sprintf("a%d", i), NumValues: numValues}
+		for v := 0; v < numValues; v++ {
+			at.Values = append(at.Values, fmt.Sprintf("v%d", v))
+		}
+		sample.AttributeTypes = append(sample.AttributeTypes, at)
+	}
+	sample.Examples = make(parse.Examples, rows)
+	for r := range sample.Examples {
+		codes := make([]int, attributes)
+		values := make([]string, attributes)
+		for i, at := range sample.AttributeTypes {
+			codes[i] = rng.Intn(at.NumValues)
+			values[i] = at.Values[codes[i]]
+		}
+		target := "no"
+		if (codes[0]+codes[1]*codes[2])%3 == 0 {
+			target = "yes"
+		}
+		if rng.Float64() < 0.05 {
+			target = sample.Targets[rng.Intn(2)]
+		}
+		sample.Examples[r] = parse.Example{StringValues: values, Target: target}
+	}
+
+	return sample
+}
+
+func benchmarkBuildTree(rows int, b *testing.B) {
+	benchmarkBuildTreeContext(rows, Options{}, b)
+}
+
+func benchmarkBuildTreeContext(rows int, opts Options, b *testing.B) {
+	sample := syntheticSample(rows, 8)
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := BuildTreeContext(context.Background(), sample, opts); err != nil {
+			b.Fatalf("building tree: %s", err.Error())
+		}
+	}
+}
+
+func BenchmarkBuildTree1k(b *testing.B)   { benchmarkBuildTree(1000, b) }
+func BenchmarkBuildTree10k(b *testing.B)  { benchmarkBuildTree(10000, b) }
+func BenchmarkBuildTree100k(b *testing.B) { benchmarkBuildTree(100000, b) }
+
+func BenchmarkBuildTreeContext100kWorkers(b *testing.B) {
+	benchmarkBuildTreeContext(100000, Options{Workers: runtime.NumCPU()}, b)
+}
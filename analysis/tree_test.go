@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/PaluMacil/decisive-oak/parse"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -16,10 +18,7 @@ func Test_getAttributeTypes(t *testing.T) {
 	if err != nil {
 		t.Errorf("building analysis sample failed: %s", err.Error())
 	}
-	ats, err := getAttributeTypes(sampleData, sample.Entropy)
-	if err != nil {
-		t.Errorf("getting attribute types: %s", err.Error())
-	}
+	ats := sample.getAttributeTypes()
 	pulseGain := fmt.Sprintf("%.3f", ats[0].Gain)
 	if pulseGain != "0.020" {
 		t.Errorf("pulse gain should be 0.020, got %s", pulseGain)
@@ -152,3 +151,177 @@ var n3 *Node
 var n4 *Node
 var n5 *Node
 var n6 *Node
+
+func TestNode_Classify(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree failed: %s", err.Error())
+	}
+	for i, eg := range sample.Examples {
+		label, err := tree.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil {
+			t.Errorf("classifying example %d: %s", i, err.Error())
+		}
+		if label != eg.Target {
+			t.Errorf("example %d: expected %s, got %s", i, eg.Target, label)
+		}
+	}
+	if _, err = tree.Classify(map[string]string{"Forecast": "Snowy"}); err == nil {
+		t.Error("expected error classifying an unseen value")
+	}
+}
+
+func TestBuildTree_Weighted(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	// weighting an example by three builds the same tree as repeating it three times
+	duplicated := sample
+	duplicated.Examples = append(parse.Examples{}, sample.Examples...)
+	duplicated.Examples = append(duplicated.Examples, sample.Examples[5], sample.Examples[5])
+	weighted := sample
+	weighted.Examples = append(parse.Examples{}, sample.Examples...)
+	weighted.Examples[5].Weight = 3
+	duplicatedTree, err := BuildTree(duplicated)
+	if err != nil {
+		t.Fatalf("building duplicated tree: %s", err.Error())
+	}
+	weightedTree, err := BuildTree(weighted)
+	if err != nil {
+		t.Fatalf("building weighted tree: %s", err.Error())
+	}
+	if !sameTree(duplicatedTree, weightedTree) {
+		t.Error("weighted tree differs from tree over duplicated examples")
+	}
+
+	// a heavy minority example outweighs the majority at a leaf without more attributes
+	conflict := parse.Sample{
+		NumTargets:     2,
+		Targets:        parse.Targets{"yes", "no"},
+		NumAttributes:  1,
+		AttributeTypes: parse.AttributeTypes{{Name: "a", NumValues: 1, Values: []string{"x"}}},
+		Examples: parse.Examples{
+			{StringValues: []string{"x"}, Target: "yes", Weight: 2.5},
+			{StringValues: []string{"x"}, Target: "no"},
+			{StringValues: []string{"x"}, Target: "no"},
+		},
+	}
+	tree, err := BuildTree(conflict)
+	if err != nil {
+		t.Fatalf("building conflicting tree: %s", err.Error())
+	}
+	leaf := tree.Children[0]
+	if leaf.Label != "yes" || leaf.TargetCounts["yes"] != 2.5 || leaf.TargetCounts["no"] != 2 {
+		t.Errorf("expected leaf yes with counts 2.5 and 2, got %s with %v", leaf.Label, leaf.TargetCounts)
+	}
+}
+
+// negativeGainData has a node at b=y where every example of each value of a is
+// split evenly between the targets, and the gain of a rounds to slightly below zero
+const negativeGainData = `2
+yes,no
+2
+a,3,x,y,z
+b,3,x,y,z
+28
+z,z,yes
+z,y,no
+x,z,no
+y,x,no
+z,x,no
+y,y,no
+y,y,yes
+x,y,no
+x,y,yes
+x,x,no
+z,z,yes
+x,x,yes
+x,x,no
+z,y,yes
+z,z,no
+x,y,no
+x,x,yes
+z,x,no
+z,x,no
+y,x,yes
+x,x,no
+x,z,no
+x,y,yes
+z,y,no
+x,z,no
+y,x,no
+z,y,yes
+z,x,no
+`
+
+func TestBuildTree_NegativeGain(t *testing.T) {
+	quietly(t)
+	sample, err := parse.Parse(strings.NewReader(negativeGainData))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %v", err)
+	}
+	if tree.Label != "b" || len(tree.Children) != 3 {
+		t.Fatalf("expected the root to split on b, got %s", tree.Label)
+	}
+	// the tie goes to the first declared target
+	leaf := tree.Children[1]
+	if !leaf.Terminal || leaf.Label != "yes" || leaf.TargetCounts["yes"] != 5 || leaf.TargetCounts["no"] != 5 {
+		t.Errorf("expected b=y to be a majority leaf, got %s with %v", leaf.Label, leaf.TargetCounts)
+	}
+}
+
+func TestBuildTree_Ordinal(t *testing.T) {
+	quietly(t)
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	age, err := sample.AttributeTypes.Index("age")
+	if err != nil {
+		t.Fatalf("finding age: %s", err.Error())
+	}
+	sample.AttributeTypes[age].Ordinal = true
+	tree, err := BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	for i, eg := range sample.Examples {
+		label, err := tree.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example %d: %s", i, err.Error())
+		}
+		if label != eg.Target {
+			t.Errorf("example %d: expected %s, got %s", i, eg.Target, label)
+		}
+	}
+	// young and pre-presbyopic patients without astigmatism and a normal tear rate
+	// are all soft, so they share a branch rather than having one each
+	node, _ := tree.child("no")
+	node, _ = node.child("normal")
+	if node.Label != "age" || len(node.Children) != 2 {
+		t.Fatalf("expected a binary split on age, got %s with %d children", node.Label, len(node.Children))
+	}
+	lower, upper := node.Children[0], node.Children[1]
+	if lower.FilterOp != "<=" || lower.FilterValue != "pre-presbyopic" || lower.Label != "soft" ||
+		!reflect.DeepEqual(lower.FilterValues, []string{"young", "pre-presbyopic"}) {
+		t.Errorf("unexpected lower range %s %s %v labelled %s",
+			lower.FilterOp, lower.FilterValue, lower.FilterValues, lower.Label)
+	}
+	if upper.FilterOp != ">" || !reflect.DeepEqual(upper.FilterValues, []string{"presbyopic"}) {
+		t.Errorf("unexpected upper range %s %s %v", upper.FilterOp, upper.FilterValue, upper.FilterValues)
+	}
+	if node.Sample.BestGainAttribute.Cut != 2 {
+		t.Errorf("expected a cut after two values, got %d", node.Sample.BestGainAttribute.Cut)
+	}
+}
@@ -0,0 +1,332 @@
+// Package boost trains AdaBoost ensembles of depth-limited trees, using the SAMME
+// variant so that samples with more than two targets can be boosted.
+package boost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	// DefaultRounds is the number of trees trained when Options does not give one.
+	DefaultRounds = 50
+	// DefaultPatience is the number of rounds without improvement on the validation
+	// sample after which training stops when Options does not give one.
+	DefaultPatience = 5
+	// minErrorRate stands in for the error rate of a tree that classifies its
+	// training sample perfectly, whose weight would otherwise be infinite.
+	minErrorRate = 1e-10
+	// minWeight keeps the weights passed to the trees positive once the boosting
+	// weight of an example has all but vanished, as parse requires.
+	minWeight = 1e-12
+)
+
+// Options controls how an ensemble is trained.
+type Options struct {
+	// Rounds is the largest number of trees to train, DefaultRounds when zero.
+	Rounds int
+	// LearningRate shrinks the weight of each tree, one when zero. Smaller rates
+	// usually need more rounds but overfit less.
+	LearningRate float64
+	// Tree controls how each tree is built. A MaxDepth of zero builds stumps.
+	Tree analysis.Options
+	// Validation, when given, is classified by the ensemble after every round.
+	// Training stops once Patience rounds pass without its weighted error falling,
+	// and the ensemble is cut back to the round where the error was least.
+	Validation *parse.Sample
+	// Patience is the number of rounds to wait for the validation error to fall,
+	// DefaultPatience when zero.
+	Patience int
+}
+
+// Round is one tree of an ensemble and the weight of its vote.
+type Round struct {
+	Tree   analysis.Node
+	Weight float64
+	// Error is the weighted error rate of the tree on the boosted training sample.
+	Error float64
+	// ValidationError is the weighted error rate of the ensemble up to and including
+	// this round on the validation sample, when there was one.
+	ValidationError float64 `json:",omitempty"`
+}
+
+// Model is a trained ensemble. It classifies by summing the weights of the trees
+// voting for each target and choosing the target with the largest total.
+type Model struct {
+	Targets parse.Targets
+	Rounds  []Round
+}
+
+// Train boosts trees over the sample. Each round builds a tree from the sample with
+// its examples weighted by how hard the earlier trees found them, then raises the
+// weight of the examples the new tree misclassifies. Training ends after
+// opts.Rounds trees, when a tree is no better than chance, when a tree makes no
+// mistakes, or when the validation error stops falling.
+func Train(ctx context.Context, sample parse.Sample, opts Options) (*Model, error) {
+	if sample.RealTarget != "" {
+		return nil, fmt.Errorf("boosting needs a nominal target, not the real target %s", sample.RealTarget)
+	}
+	if opts.Validation != nil && opts.Validation.RealTarget != "" {
+		return nil, fmt.Errorf("validation sample has the real target %s", opts.Validation.RealTarget)
+	}
+	if opts.Rounds < 0 || opts.Patience < 0 || opts.LearningRate < 0 {
+		return nil, fmt.Errorf("rounds %d, patience %d and learning rate %v must not be negative",
+			opts.Rounds, opts.Patience, opts.LearningRate)
+	}
+	if opts.Rounds == 0 {
+		opts.Rounds = DefaultRounds
+	}
+	if opts.Patience == 0 {
+		opts.Patience = DefaultPatience
+	}
+	if opts.LearningRate == 0 {
+		opts.LearningRate = 1
+	}
+	if opts.Tree.MaxDepth == 0 {
+		opts.Tree.MaxDepth = 1
+	}
+	training, err := newExamples(sample)
+	if err != nil {
+		return nil, fmt.Errorf("preparing training sample: %w", err)
+	}
+	numTargets := training.presentTargets()
+	if numTargets < 2 {
+		return nil, fmt.Errorf("boosting needs examples of at least two targets, found %d", numTargets)
+	}
+	var validation *examples
+	var validationScores []map[string]float64
+	if opts.Validation != nil {
+		if validation, err = newExamples(*opts.Validation); err != nil {
+			return nil, fmt.Errorf("preparing validation sample: %w", err)
+		}
+		validationScores = make([]map[string]float64, len(validation.values))
+		for i := range validationScores {
+			validationScores[i] = make(map[string]float64)
+		}
+	}
+	// boosting weights start in proportion to the examples' own weights
+	weights := make([]float64, len(training.weights))
+	copy(weights, training.weights)
+	normalize(weights)
+
+	m := &Model{Targets: sample.Targets}
+	best, bestError := 0, math.Inf(1)
+	for round := 0; round < opts.Rounds; round++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		boosted := sample
+		boosted.Examples = make(parse.Examples, len(sample.Examples))
+		for i, eg := range sample.Examples {
+			eg.Weight = math.Max(weights[i]*float64(len(weights)), minWeight)
+			boosted.Examples[i] = eg
+		}
+		tree, err := analysis.BuildTreeContext(ctx, boosted, opts.Tree)
+		if err != nil {
+			return nil, fmt.Errorf("building tree for round %d: %w", round+1, err)
+		}
+		// examples the tree cannot classify count as mistakes
+		wrong := make([]bool, len(weights))
+		var errorRate float64
+		for i, values := range training.values {
+			label, err := tree.Classify(values)
+			if err != nil || label != training.targets[i] {
+				wrong[i] = true
+				errorRate += weights[i]
+			}
+		}
+		if errorRate >= 1-1/float64(numTargets) {
+			if round == 0 {
+				return nil, fmt.Errorf("the first tree has error rate %.3f, no better than chance", errorRate)
+			}
+			break
+		}
+		perfect := errorRate < minErrorRate
+		weight := opts.LearningRate * (math.Log((1-errorRate)/math.Max(errorRate, minErrorRate)) +
+			math.Log(float64(numTargets-1)))
+		r := Round{Tree: tree, Weight: weight, Error: errorRate}
+		if validation != nil {
+			r.ValidationError = validation.addVotes(tree, weight, validationScores, m.Targets)
+		}
+		m.Rounds = append(m.Rounds, r)
+		if validation != nil {
+			if r.ValidationError < bestError {
+				best, bestError = len(m.Rounds), r.ValidationError
+			} else if len(m.Rounds)-best >= opts.Patience {
+				break
+			}
+		}
+		if perfect {
+			break
+		}
+		for i := range weights {
+			if wrong[i] {
+				weights[i] *= math.Exp(weight)
+			}
+		}
+		normalize(weights)
+	}
+	if validation != nil {
+		m.Rounds = m.Rounds[:best]
+	}
+
+	return m, nil
+}
+
+// examples holds the values, targets and weights of a sample's examples.
+type examples struct {
+	values  []map[string]string
+	targets []string
+	weights []float64
+}
+
+func newExamples(sample parse.Sample) (*examples, error) {
+	e := &examples{
+		values:  make([]map[string]string, len(sample.Examples)),
+		targets: make([]string, len(sample.Examples)),
+		weights: make([]float64, len(sample.Examples)),
+	}
+	for i, eg := range sample.Examples {
+		values, err := sample.AttributeTypes.ExampleValues(eg)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		e.values[i], e.targets[i], e.weights[i] = values, eg.Target, eg.EffectiveWeight()
+	}
+
+	return e, nil
+}
+
+// presentTargets returns the number of distinct targets of the examples.
+func (e *examples) presentTargets() int {
+	present := make(map[string]bool)
+	for _, t := range e.targets {
+		present[t] = true
+	}
+
+	return len(present)
+}
+
+// addVotes adds the tree's weighted votes to the scores of each example and returns
+// the weighted error rate of the scores so far. Examples no tree has voted for are
+// counted as mistakes.
+func (e *examples) addVotes(tree analysis.Node, weight float64, scores []map[string]float64,
+	targets parse.Targets) float64 {
+	var wrong, total float64
+	for i, values := range e.values {
+		if label, err := tree.Classify(values); err == nil {
+			scores[i][label] += weight
+		}
+		total += e.weights[i]
+		if label, ok := best(scores[i], targets); !ok || label != e.targets[i] {
+			wrong += e.weights[i]
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return wrong / total
+}
+
+func normalize(weights []float64) {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	for i := range weights {
+		weights[i] /= sum
+	}
+}
+
+// best returns the target with the highest score, preferring targets declared
+// first on ties, and false when no target has a score.
+func best(scores map[string]float64, targets parse.Targets) (string, bool) {
+	var label string
+	found := false
+	for _, t := range targets {
+		if s, ok := scores[t]; ok && (!found || s > scores[label]) {
+			label, found = t, true
+		}
+	}
+
+	return label, found
+}
+
+// Scores returns the total weight of the trees voting for each target given values
+// keyed by attribute name. A tree that cannot classify the values, such as one
+// meeting a value it has no branch for, does not vote.
+func (m *Model) Scores(values map[string]string) (map[string]float64, error) {
+	scores := make(map[string]float64)
+	var lastErr error
+	for _, r := range m.Rounds {
+		label, err := r.Tree.Classify(values)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		scores[label] += r.Weight
+	}
+	if len(scores) == 0 {
+		if lastErr == nil {
+			return nil, fmt.Errorf("scoring: the model has no trees")
+		}
+		return nil, fmt.Errorf("scoring: no tree could classify the values: %w", lastErr)
+	}
+
+	return scores, nil
+}
+
+// Classify returns the target with the most weight voting for it given values keyed
+// by attribute name, preferring targets declared first on ties.
+func (m *Model) Classify(values map[string]string) (string, error) {
+	scores, err := m.Scores(values)
+	if err != nil {
+		return "", err
+	}
+	label, ok := best(scores, m.Targets)
+	if !ok {
+		return "", fmt.Errorf("classifying: the trees voted only for undeclared targets")
+	}
+
+	return label, nil
+}
+
+// ClassifyExample classifies a parsed example whose values are laid out according
+// to the given attribute types.
+func (m *Model) ClassifyExample(attributeTypes parse.AttributeTypes, eg parse.Example) (string, error) {
+	values, err := attributeTypes.ExampleValues(eg)
+	if err != nil {
+		return "", fmt.Errorf("classifying example: %w", err)
+	}
+
+	return m.Classify(values)
+}
+
+// FromFile reads a model written by Write.
+func FromFile(filename string) (*Model, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	defer file.Close()
+	var m Model
+	if err = json.NewDecoder(file).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filename, err)
+	}
+
+	return &m, nil
+}
+
+// Write encodes the model's targets and rounds, trees included, as JSON.
+func (m *Model) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
@@ -0,0 +1,124 @@
+package boost
+
+import (
+	"bytes"
+	"context"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func accuracy(t *testing.T, classify func(parse.AttributeTypes, parse.Example) (string, error), sample parse.Sample) float64 {
+	t.Helper()
+	var correct int
+	for _, eg := range sample.Examples {
+		label, err := classify(sample.AttributeTypes, eg)
+		if err == nil && label == eg.Target {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(len(sample.Examples))
+}
+
+func TestTrain(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	tree, err := analysis.BuildTreeContext(context.Background(), sample, analysis.Options{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("building tree: %v", err)
+	}
+	m, err := Train(context.Background(), sample, Options{Rounds: 20, Tree: analysis.Options{MaxDepth: 2}})
+	if err != nil {
+		t.Fatalf("training: %v", err)
+	}
+	if len(m.Rounds) < 2 {
+		t.Fatalf("expected several rounds, got %d", len(m.Rounds))
+	}
+	for i, r := range m.Rounds {
+		if !(r.Weight > 0) || r.Error >= 2.0/3 {
+			t.Errorf("round %d has weight %v and error %v", i+1, r.Weight, r.Error)
+		}
+	}
+	treeAccuracy, boostedAccuracy := accuracy(t, tree.ClassifyExample, sample), accuracy(t, m.ClassifyExample, sample)
+	if boostedAccuracy <= treeAccuracy {
+		t.Errorf("expected boosting to beat a single tree's accuracy %v, got %v", treeAccuracy, boostedAccuracy)
+	}
+
+	var buf bytes.Buffer
+	if err = m.Write(&buf); err != nil {
+		t.Fatalf("writing model: %v", err)
+	}
+	filename := filepath.Join(t.TempDir(), "lenses.boost.json")
+	if err = ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("saving model: %v", err)
+	}
+	read, err := FromFile(filename)
+	if err != nil {
+		t.Fatalf("reading model: %v", err)
+	}
+	if len(read.Rounds) != len(m.Rounds) {
+		t.Fatalf("expected %d rounds to be read, got %d", len(m.Rounds), len(read.Rounds))
+	}
+	for i, eg := range sample.Examples {
+		want, _ := m.ClassifyExample(sample.AttributeTypes, eg)
+		got, err := read.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil || got != want {
+			t.Errorf("example %d: expected %s from the read model, got %s (%v)", i, want, got, err)
+		}
+	}
+}
+
+func TestTrain_EarlyStopping(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	m, err := Train(context.Background(), sample, Options{Rounds: 50, Validation: &sample, Patience: 3})
+	if err != nil {
+		t.Fatalf("training: %v", err)
+	}
+	if len(m.Rounds) == 0 || len(m.Rounds) >= 50 {
+		t.Fatalf("expected training to stop early, got %d rounds", len(m.Rounds))
+	}
+	last := m.Rounds[len(m.Rounds)-1].ValidationError
+	for i, r := range m.Rounds {
+		if r.ValidationError < last {
+			t.Errorf("round %d has validation error %v below the final %v", i+1, r.ValidationError, last)
+		}
+	}
+	if got := 1 - accuracy(t, m.ClassifyExample, sample); got != last {
+		t.Errorf("expected the kept rounds to have validation error %v, got %v", last, got)
+	}
+}
+
+func TestTrain_Invalid(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	if _, err = Train(context.Background(), sample, Options{Rounds: -1}); err == nil {
+		t.Error("expected an error for negative rounds")
+	}
+	real := sample
+	real.RealTarget, real.Targets = "lenses", nil
+	if _, err = Train(context.Background(), real, Options{}); err == nil {
+		t.Error("expected an error for a real target")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = Train(ctx, sample, Options{}); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,941 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/boost"
+	"github.com/PaluMacil/decisive-oak/discretize"
+	"github.com/PaluMacil/decisive-oak/evaluate"
+	"github.com/PaluMacil/decisive-oak/learner"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"github.com/PaluMacil/decisive-oak/profile"
+	"github.com/PaluMacil/decisive-oak/quality"
+	"github.com/PaluMacil/decisive-oak/resample"
+	"github.com/PaluMacil/decisive-oak/tune"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// commands are run by name as the first command line argument. With no arguments,
+// every data file in the data directory is processed.
+var commands = map[string]func(args []string){
+	"boost":        boostCommand,
+	"classify":     classifyCommand,
+	"compare":      compareCommand,
+	"discretize":   discretizeCommand,
+	"evaluate":     evaluateCommand,
+	"importance":   importanceCommand,
+	"infer-schema": inferSchemaCommand,
+	"profile":      profileCommand,
+	"quality":      qualityCommand,
+	"relevance":    relevanceCommand,
+	"stream":       streamCommand,
+	"tree":         treeCommand,
+	"tune":         tuneCommand,
+}
+
+func runCommand(name string, args []string) {
+	command, ok := commands[name]
+	if !ok {
+		var names []string
+		for n := range commands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Printf("unknown command %s, expected one of %s\n", name, strings.Join(names, ", "))
+		os.Exit(2)
+	}
+	command(args)
+}
+
+func inferSchemaCommand(args []string) {
+	flags := flag.NewFlagSet("infer-schema", flag.ExitOnError)
+	header := flags.Bool("header", false, "the first line names the columns")
+	target := flags.String("target", "", "name of the target column, defaulting to the last column")
+	weight := flags.String("weight", "", "name of a column of example weights")
+	maxValues := flags.Int("max-values", 20, "most distinct values for a column to be proposed as nominal")
+	output := flags.String("o", "", "file to write the schema to instead of standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak infer-schema [flags] data.csv")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("opening %s: %v\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	schema, err := parse.InferSchema(file, parse.InferOptions{
+		Header:           *header,
+		Target:           *target,
+		Weight:           *weight,
+		MaxNominalValues: *maxValues,
+	})
+	if err != nil {
+		fmt.Printf("inferring schema of %s: %v\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			fmt.Printf("creating schema file: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	if err = schema.Write(out); err != nil {
+		fmt.Printf("writing schema: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func treeCommand(args []string) {
+	flags := flag.NewFlagSet("tree", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	binarySplits := flags.Bool("binary-splits", false, "let nominal attributes split their values into two sets")
+	criterion := flags.String("criterion", string(analysis.Variance), "impurity of a real target: variance or mae")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak tree [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	// leaves of conflicting examples take the majority target without a split to explain them
+	if report, err := quality.Find(sample); err == nil && len(report.Conflicts) > 0 {
+		fmt.Printf("warning: examples with the same values have different targets in %d groups; "+
+			"see decisive-oak quality\n", len(report.Conflicts))
+	}
+	rootNode, err := analysis.BuildTreeContext(context.Background(), sample, analysis.Options{
+		BinarySplits: *binarySplits,
+		Criterion:    analysis.Criterion(*criterion),
+	})
+	if err != nil {
+		fmt.Printf("building tree failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+	treeFilename := outputFilename(flags.Arg(0), ".tree.json")
+	writeJSON(treeFilename, rootNode)
+	fmt.Printf("Wrote %s\n", treeFilename)
+}
+
+func discretizeCommand(args []string) {
+	flags := flag.NewFlagSet("discretize", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	method := flags.String("method", string(discretize.EqualWidth), "width, frequency or mdl")
+	bins := flags.Int("bins", discretize.DefaultBins, "number of bins for the width and frequency methods")
+	attributes := flags.String("attributes", "", "comma-separated real attributes to discretize, defaulting to all")
+	apply := flags.String("apply", "", "bins file written by an earlier run to apply instead of fitting new bins")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak discretize [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	var d *discretize.Discretizer
+	var err error
+	if *apply != "" {
+		d, err = discretize.FromFile(*apply)
+	} else {
+		opts := discretize.Options{Method: discretize.Method(*method), Bins: *bins}
+		if *attributes != "" {
+			opts.Attributes = strings.Split(*attributes, ",")
+		}
+		d, err = discretize.Fit(sample, opts)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	discrete, err := d.Apply(sample)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	// the bins are written first, so that a data file is never left without them
+	if *apply == "" {
+		binsFilename := outputFilename(flags.Arg(0), ".bins.json")
+		binsFile, err := os.Create(binsFilename)
+		if err != nil {
+			fmt.Printf("creating %s: %v\n", binsFilename, err)
+			os.Exit(1)
+		}
+		err = d.Write(binsFile)
+		binsFile.Close()
+		if err != nil {
+			fmt.Printf("writing %s: %v\n", binsFilename, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", binsFilename)
+	}
+	dataFilename := outputFilename(flags.Arg(0), ".discrete.data.txt")
+	if err = parse.ToFile(dataFilename, discrete); err != nil {
+		fmt.Printf("writing %s: %v\n", dataFilename, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s\n", dataFilename)
+}
+
+func evaluateCommand(args []string) {
+	flags := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	classWeights := flags.String("class-weights", "", "balanced, or target=weight pairs separated by commas")
+	method := flags.String("resample", "", "resample the training examples: over, under or smote")
+	neighbours := flags.Int("k", 5, "nearest neighbours considered by smote")
+	costsFile := flags.String("costs", "", "JSON cost matrix used to label leaves, prune and score predictions")
+	binarySplits := flags.Bool("binary-splits", false, "let nominal attributes split their values into two sets")
+	criterion := flags.String("criterion", string(analysis.Variance), "impurity of a real target: variance or mae")
+	unseen := flags.String("unseen", "", "classify values unseen in training with the error, parent, distribute or frequent strategy")
+	pruneFraction := flags.Float64("prune", 0, "fraction of each target's training examples held out to prune the tree with")
+	testFraction := flags.Float64("test-fraction", 0.3, "fraction of each target's examples held out for testing")
+	seed := flags.Int64("seed", 1, "seed for the holdout split and resampling")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak evaluate [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	opts, err := classWeightOptions(*classWeights)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	opts.BinarySplits = *binarySplits
+	opts.Criterion = analysis.Criterion(*criterion)
+	if *costsFile != "" {
+		opts.Costs, err = analysis.CostMatrixFromFile(*costsFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+	var classifier *analysis.Classifier
+	if *unseen != "" {
+		strategy, err := analysis.ParseUnseenStrategy(*unseen)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(2)
+		}
+		classifier = &analysis.Classifier{Unseen: strategy, Costs: opts.Costs}
+	}
+	checkFraction("test-fraction", *testFraction)
+	checkFraction("prune", *pruneFraction)
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	if sample.RealTarget != "" && (*method != "" || *pruneFraction > 0 || classifier != nil) {
+		fmt.Printf("-resample, -prune and -unseen do not apply to the real target %s\n", sample.RealTarget)
+		os.Exit(2)
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	train, test := holdout(sample, *testFraction, rng)
+	var validation parse.Sample
+	if *pruneFraction > 0 {
+		train, validation = holdout(train, *pruneFraction, rng)
+	}
+	switch *method {
+	case "":
+	case "over":
+		train = resample.Oversample(train, rng)
+	case "under":
+		train = resample.Undersample(train, rng)
+	case "smote":
+		train, err = resample.SMOTE(train, *neighbours, rng)
+	default:
+		err = fmt.Errorf("unknown resampling method %s, expected over, under or smote", *method)
+	}
+	if err != nil {
+		fmt.Printf("resampling: %v\n", err)
+		os.Exit(1)
+	}
+	analysis.Output = ioutil.Discard
+	rootNode, err := analysis.BuildTreeContext(context.Background(), train, opts)
+	if err != nil {
+		fmt.Printf("building tree failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if *pruneFraction > 0 {
+		removed, err := rootNode.Prune(validation, opts.Costs)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("pruned %d nodes with %d examples\n", removed, len(validation.Examples))
+	}
+	if sample.RealTarget != "" {
+		residuals, err := evaluate.Regression(rootNode, test)
+		if err != nil {
+			fmt.Printf("evaluating tree: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("trained on %d examples, tested on %d\n", len(train.Examples), len(test.Examples))
+		fmt.Print(residuals.String())
+		return
+	}
+	var confusion *evaluate.Confusion
+	if classifier != nil {
+		classifier.Tree = rootNode
+		confusion, err = evaluate.Classifier(*classifier, test)
+	} else {
+		confusion, err = evaluate.TreeWithCosts(rootNode, test, opts.Costs)
+	}
+	if err != nil {
+		fmt.Printf("evaluating tree: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("trained on %d examples, tested on %d\n", len(train.Examples), len(test.Examples))
+	fmt.Print(confusion.String())
+	if opts.Costs != nil {
+		cost, err := confusion.Cost(opts.Costs)
+		if err != nil {
+			fmt.Printf("scoring predictions: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("cost: %g", cost)
+		if total := confusion.Total(); total > 0 {
+			fmt.Printf(", %.3f per example", cost/total)
+		}
+		fmt.Println()
+	}
+}
+
+func boostCommand(args []string) {
+	flags := flag.NewFlagSet("boost", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	binarySplits := flags.Bool("binary-splits", false, "let nominal attributes split their values into two sets")
+	rounds := flags.Int("rounds", boost.DefaultRounds, "largest number of trees to train")
+	depth := flags.Int("depth", 1, "maximum depth of each tree")
+	learningRate := flags.Float64("learning-rate", 1, "factor shrinking the weight of each tree")
+	validationFraction := flags.Float64("validation-fraction", 0, "fraction of each target's training examples held out to stop early with")
+	patience := flags.Int("patience", boost.DefaultPatience, "rounds without a lower validation error before stopping")
+	testFraction := flags.Float64("test-fraction", 0.3, "fraction of each target's examples held out for testing")
+	seed := flags.Int64("seed", 1, "seed for the holdout splits")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak boost [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	checkFraction("test-fraction", *testFraction)
+	checkFraction("validation-fraction", *validationFraction)
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	rng := rand.New(rand.NewSource(*seed))
+	train, test := holdout(sample, *testFraction, rng)
+	opts := boost.Options{
+		Rounds:       *rounds,
+		LearningRate: *learningRate,
+		Tree:         analysis.Options{MaxDepth: *depth, BinarySplits: *binarySplits},
+		Patience:     *patience,
+	}
+	if *validationFraction > 0 {
+		var validation parse.Sample
+		train, validation = holdout(train, *validationFraction, rng)
+		opts.Validation = &validation
+	}
+	analysis.Output = ioutil.Discard
+	model, err := boost.Train(context.Background(), train, opts)
+	if err != nil {
+		fmt.Printf("boosting failed: %v\n", err)
+		os.Exit(1)
+	}
+	for i, r := range model.Rounds {
+		fmt.Printf("round %d: %s tree, error %.3f, weight %.3f", i+1, r.Tree.Label, r.Error, r.Weight)
+		if opts.Validation != nil {
+			fmt.Printf(", validation error %.3f", r.ValidationError)
+		}
+		fmt.Println()
+	}
+	confusion, err := evaluate.Model(model, test)
+	if err != nil {
+		fmt.Printf("evaluating model: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("trained on %d examples, tested on %d\n", len(train.Examples), len(test.Examples))
+	fmt.Print(confusion.String())
+	modelFilename := outputFilename(flags.Arg(0), ".boost.json")
+	writeJSON(modelFilename, model)
+	fmt.Printf("Wrote %s\n", modelFilename)
+}
+
+func streamCommand(args []string) {
+	flags := flag.NewFlagSet("stream", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing headerless data")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	delta := flags.Float64("delta", 1e-7, "probability of splitting on a different attribute than the whole stream would")
+	tieThreshold := flags.Float64("tie-threshold", 0.05, "Hoeffding bound below which the best two attributes are treated as tied")
+	gracePeriod := flags.Float64("grace-period", 200, "weight of examples a leaf receives between attempts to split")
+	maxDepth := flags.Int("max-depth", 0, "deepest a node may be, zero for no limit")
+	every := flags.Int("snapshot-every", 0, "write the tree after every n examples as well as at the end")
+	name := flags.String("name", "stream", "name of the tree file when reading standard input")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak stream [flags] data-file|-")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	input, treeFilename := os.Stdin, outputFilename(*name, ".tree.json")
+	if flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Printf("opening %s: %v\n", flags.Arg(0), err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		input, treeFilename = file, outputFilename(flags.Arg(0), ".tree.json")
+	}
+	opts := parse.ParseOptions{File: flags.Arg(0), Lenient: *lenient}
+	var r *parse.Reader
+	var err error
+	if *schemaFile != "" {
+		schema, schemaErr := parse.SchemaFromFile(*schemaFile)
+		if schemaErr != nil {
+			fmt.Printf("reading schema: %v\n", schemaErr)
+			os.Exit(1)
+		}
+		r, err = parse.NewSchemaReader(input, schema, opts)
+	} else {
+		r, err = parse.NewReader(input, opts)
+	}
+	if err != nil {
+		fmt.Printf("reading header: %v\n", err)
+		os.Exit(1)
+	}
+	h, err := analysis.NewHoeffdingTree(r.Header(), analysis.HoeffdingOptions{
+		Delta:        *delta,
+		TieThreshold: *tieThreshold,
+		GracePeriod:  *gracePeriod,
+		MaxDepth:     *maxDepth,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	var count int
+	for {
+		eg, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = h.Learn(eg)
+		}
+		if err != nil {
+			fmt.Printf("example %d: %v\n", count+1, err)
+			os.Exit(1)
+		}
+		count++
+		if *every > 0 && count%*every == 0 {
+			writeJSON(treeFilename, h.Snapshot())
+			fmt.Printf("Wrote %s after %d examples\n", treeFilename, count)
+		}
+	}
+	if errs := r.Errors(); len(errs) > 0 {
+		fmt.Println(errs.Error())
+	}
+	writeJSON(treeFilename, h.Snapshot())
+	fmt.Printf("Wrote %s after %d examples\n", treeFilename, count)
+}
+
+func compareCommand(args []string) {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	binarySplits := flags.Bool("binary-splits", false, "let nominal attributes split their values into two sets")
+	unseen := flags.String("unseen", "error", "classify values unseen in training with the error, parent, distribute or frequent strategy")
+	alpha := flags.Float64("alpha", 1, "count added to every naive Bayes count")
+	testFraction := flags.Float64("test-fraction", 0.3, "fraction of each target's examples held out for testing")
+	seed := flags.Int64("seed", 1, "seed for the holdout split")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak compare [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	strategy, err := analysis.ParseUnseenStrategy(*unseen)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	checkFraction("test-fraction", *testFraction)
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	train, test := holdout(sample, *testFraction, rand.New(rand.NewSource(*seed)))
+	learners := []struct {
+		name    string
+		learner learner.Learner
+	}{
+		{"id3", learner.Tree{Options: analysis.Options{BinarySplits: *binarySplits}, Unseen: strategy}},
+		{"zero-r", learner.ZeroR{}},
+		{"one-r", learner.OneR{}},
+		{"naive-bayes", learner.NaiveBayes{Alpha: *alpha}},
+	}
+	analysis.Output = ioutil.Discard
+	fmt.Printf("trained on %d examples, tested on %d\n", len(train.Examples), len(test.Examples))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "learner\taccuracy\tunclassified\t\n")
+	for _, l := range learners {
+		confusion, err := evaluate.Learner(context.Background(), l.learner, train, test)
+		if err != nil {
+			tw.Flush()
+			fmt.Printf("evaluating %s: %v\n", l.name, err)
+			os.Exit(1)
+		}
+		var unclassified float64
+		for _, u := range confusion.Unclassified {
+			unclassified += u
+		}
+		fmt.Fprintf(tw, "%s\t%.3f\t%g\t\n", l.name, confusion.Accuracy(), unclassified)
+	}
+	tw.Flush()
+}
+
+func classifyCommand(args []string) {
+	flags := flag.NewFlagSet("classify", flag.ExitOnError)
+	unseen := flags.String("unseen", "error", "classify values unseen in training with the error, parent, distribute or frequent strategy")
+	costsFile := flags.String("costs", "", "JSON cost matrix used to predict from combined target counts")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak classify [flags] tree-file attribute=value...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	strategy, err := analysis.ParseUnseenStrategy(*unseen)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	values := make(map[string]string)
+	for _, pair := range flags.Args()[1:] {
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			fmt.Printf("value %q is not attribute=value\n", pair)
+			os.Exit(2)
+		}
+		values[pair[:i]] = pair[i+1:]
+	}
+	classifier := analysis.Classifier{Unseen: strategy}
+	if *costsFile != "" {
+		classifier.Costs, err = analysis.CostMatrixFromFile(*costsFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+	treeData, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("reading tree: %v\n", err)
+		os.Exit(1)
+	}
+	if err = json.Unmarshal(treeData, &classifier.Tree); err != nil {
+		fmt.Printf("decoding tree %s: %v\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+	prediction, err := classifier.Predict(values)
+	for _, step := range prediction.Path {
+		fmt.Printf("%s = %q", step.Attribute, step.Value)
+		if step.Unseen != "" {
+			fmt.Printf(" unseen, %s", step.Unseen)
+		}
+		if step.Branch != "" {
+			fmt.Printf(" -> %s", step.Branch)
+		}
+		if step.Weight != 1 {
+			fmt.Printf(" (weight %.3f)", step.Weight)
+		}
+		fmt.Println()
+	}
+	if err != nil {
+		fmt.Printf("%s: %v\n", prediction.Label, err)
+		os.Exit(1)
+	}
+	fmt.Println(prediction.Label)
+}
+
+func tuneCommand(args []string) {
+	flags := flag.NewFlagSet("tune", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	classWeights := flags.String("class-weights", "", "balanced, or target=weight pairs separated by commas")
+	costsFile := flags.String("costs", "", "JSON cost matrix used to label leaves, prune and score predictions")
+	depths := flags.String("depths", "1,2,3,4,0", "maximum depths to try, separated by commas, with 0 for no limit")
+	binarySplits := flags.String("binary-splits", "false,true", "whether to let nominal attributes split their values into two sets")
+	criteria := flags.String("criteria", "variance,mae", "impurities of a real target to try")
+	prunes := flags.String("prune", "0", "fractions of each target's training examples to hold out for pruning")
+	folds := flags.Int("folds", tune.DefaultFolds, "number of cross-validation folds")
+	random := flags.Int("random", 0, "number of candidates to draw at random instead of trying every combination")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of trees to build concurrently")
+	seed := flags.Int64("seed", 1, "seed for the candidates, folds and pruning holdouts")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak tune [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	base, err := classWeightOptions(*classWeights)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	if *costsFile != "" {
+		base.Costs, err = analysis.CostMatrixFromFile(*costsFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+	var space tune.Space
+	for _, value := range strings.Split(*depths, ",") {
+		depth, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			fmt.Printf("parsing depth: %v\n", err)
+			os.Exit(2)
+		}
+		space.MaxDepth = append(space.MaxDepth, depth)
+	}
+	for _, value := range strings.Split(*binarySplits, ",") {
+		binary, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			fmt.Printf("parsing binary splits: %v\n", err)
+			os.Exit(2)
+		}
+		space.BinarySplits = append(space.BinarySplits, binary)
+	}
+	for _, value := range strings.Split(*criteria, ",") {
+		space.Criterion = append(space.Criterion, analysis.Criterion(strings.TrimSpace(value)))
+	}
+	for _, value := range strings.Split(*prunes, ",") {
+		prune, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			fmt.Printf("parsing prune fraction: %v\n", err)
+			os.Exit(2)
+		}
+		checkFraction("prune", prune)
+		space.Prune = append(space.Prune, prune)
+	}
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	analysis.Output = ioutil.Discard
+	report, err := tune.Search(context.Background(), sample, space, tune.Options{
+		Base:    base,
+		Folds:   *folds,
+		Random:  *random,
+		Seed:    *seed,
+		Workers: *workers,
+	})
+	if err != nil {
+		fmt.Printf("tuning failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(report.String())
+	rootNode, err := tune.Build(context.Background(), sample, base, report.Best, *seed)
+	if err != nil {
+		fmt.Printf("building tree failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("best: %s\n", report.Best)
+	reportFilename := outputFilename(flags.Arg(0), ".tune.json")
+	writeJSON(reportFilename, report)
+	fmt.Printf("Wrote %s\n", reportFilename)
+	treeFilename := outputFilename(flags.Arg(0), ".tree.json")
+	writeJSON(treeFilename, rootNode)
+	fmt.Printf("Wrote %s\n", treeFilename)
+}
+
+func importanceCommand(args []string) {
+	flags := flag.NewFlagSet("importance", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	binarySplits := flags.Bool("binary-splits", false, "let nominal attributes split their values into two sets")
+	criterion := flags.String("criterion", string(analysis.Variance), "impurity of a real target: variance or mae")
+	testFraction := flags.Float64("test-fraction", 0.3, "fraction of each target's examples held out to shuffle")
+	repeats := flags.Int("repeats", 10, "number of times each attribute's values are shuffled")
+	seed := flags.Int64("seed", 1, "seed for the holdout split and the shuffles")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak importance [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	checkFraction("test-fraction", *testFraction)
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	rng := rand.New(rand.NewSource(*seed))
+	train, test := holdout(sample, *testFraction, rng)
+	analysis.Output = ioutil.Discard
+	rootNode, err := analysis.BuildTreeContext(context.Background(), train, analysis.Options{
+		BinarySplits: *binarySplits,
+		Criterion:    analysis.Criterion(*criterion),
+	})
+	if err != nil {
+		fmt.Printf("building tree failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+	importances, err := evaluate.PermutationImportance(rootNode, test, *repeats, rng)
+	if err != nil {
+		fmt.Printf("measuring importance: %v\n", err)
+		os.Exit(1)
+	}
+	loss := "accuracy loss"
+	if sample.RealTarget != "" {
+		loss = "rmse rise"
+	}
+	fmt.Printf("trained on %d examples, shuffled %d\n", len(train.Examples), len(test.Examples))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "attribute\tgain\tshare\tsplits\t%s\tstd dev\t\n", loss)
+	for _, imp := range importances {
+		fmt.Fprintf(tw, "%s\t%.4f\t%.1f%%\t%d\t%.4f\t%.4f\t\n",
+			imp.Attribute, imp.Gain, imp.Share*100, imp.Splits, imp.Permutation, imp.PermutationStdDev)
+	}
+	tw.Flush()
+	treeFilename := outputFilename(flags.Arg(0), ".tree.json")
+	writeJSON(treeFilename, rootNode)
+	fmt.Printf("Wrote %s\n", treeFilename)
+	importanceFilename := outputFilename(flags.Arg(0), ".importance.json")
+	writeJSON(importanceFilename, importances)
+	fmt.Printf("Wrote %s\n", importanceFilename)
+}
+
+func profileCommand(args []string) {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	bins := flags.Int("bins", profile.DefaultBins, "number of histogram bins of each real column")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak profile [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	p, err := profile.New(sample, profile.Options{Bins: *bins})
+	if err != nil {
+		fmt.Printf("profiling: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(p.String())
+	profileFilename := outputFilename(flags.Arg(0), ".profile.json")
+	writeJSON(profileFilename, p)
+	fmt.Printf("Wrote %s\n", profileFilename)
+}
+
+func relevanceCommand(args []string) {
+	flags := flag.NewFlagSet("relevance", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	nearConstant := flags.Float64("near-constant", 0.95, "share of the examples the most common value must have to flag an attribute near-constant")
+	idLike := flags.Float64("id-like", 0.9, "ratio of distinct values to examples at which a nominal attribute is flagged id-like")
+	redundant := flags.Float64("redundant", 0.8, "Cramér's V at which a pair of attributes is listed as redundant")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak relevance [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	relevance, err := analysis.NewRelevance(sample, analysis.RelevanceOptions{NearConstant: *nearConstant, IDLike: *idLike})
+	if err != nil {
+		fmt.Printf("measuring relevance: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(relevance.String())
+	pairs := relevance.Redundant(*redundant)
+	if len(pairs) > 0 {
+		fmt.Printf("\nredundant at a cramér's v of %g or more:\n", *redundant)
+	}
+	for _, pair := range pairs {
+		fmt.Printf("%s, %s\n", pair[0], pair[1])
+	}
+	relevanceFilename := outputFilename(flags.Arg(0), ".relevance.json")
+	writeJSON(relevanceFilename, relevance)
+	fmt.Printf("Wrote %s\n", relevanceFilename)
+}
+
+func qualityCommand(args []string) {
+	flags := flag.NewFlagSet("quality", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "schema describing a headerless data file")
+	lenient := flags.Bool("lenient", false, "skip invalid examples, reporting every error")
+	clean := flags.String("clean", "", "comma-separated cleaning actions: dedupe, and resolve or drop-conflicts")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: decisive-oak quality [flags] data-file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	sample := loadSample(flags.Arg(0), *schemaFile, *lenient)
+	report, err := quality.Find(sample)
+	if err != nil {
+		fmt.Printf("checking examples: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(report.String())
+	reportFilename := outputFilename(flags.Arg(0), ".quality.json")
+	writeJSON(reportFilename, report)
+	fmt.Printf("Wrote %s\n", reportFilename)
+	if *clean == "" {
+		return
+	}
+	var actions []quality.Action
+	for _, a := range strings.Split(*clean, ",") {
+		actions = append(actions, quality.Action(a))
+	}
+	cleaned, removed, err := report.Clean(sample, actions...)
+	if err != nil {
+		fmt.Printf("cleaning examples: %v\n", err)
+		os.Exit(1)
+	}
+	dataFilename := outputFilename(flags.Arg(0), ".clean.data.txt")
+	if err = parse.ToFile(dataFilename, cleaned); err != nil {
+		fmt.Printf("writing %s: %v\n", dataFilename, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %d of %d examples\nWrote %s\n", len(removed), len(sample.Examples), dataFilename)
+}
+
+// classWeightOptions parses the -class-weights flag into build options.
+func classWeightOptions(flagValue string) (analysis.Options, error) {
+	var opts analysis.Options
+	if flagValue == "" {
+		return opts, nil
+	}
+	if flagValue == "balanced" {
+		opts.BalancedClassWeights = true
+		return opts, nil
+	}
+	opts.ClassWeights = make(map[string]float64)
+	for _, pair := range strings.Split(flagValue, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return opts, fmt.Errorf("class weight %q is not target=weight", pair)
+		}
+		weight, err := strconv.ParseFloat(pair[i+1:], 64)
+		if err != nil {
+			return opts, fmt.Errorf("class weight for %s: %w", pair[:i], err)
+		}
+		opts.ClassWeights[pair[:i]] = weight
+	}
+
+	return opts, nil
+}
+
+// checkFraction exits when the fraction given by the named flag is not at least 0
+// and less than 1, as holding out examples requires.
+func checkFraction(flagName string, fraction float64) {
+	if !(fraction >= 0 && fraction < 1) {
+		fmt.Printf("-%s %v must be at least 0 and less than 1\n", flagName, fraction)
+		os.Exit(2)
+	}
+}
+
+// holdout splits the sample with evaluate.Holdout, exiting when it cannot.
+func holdout(sample parse.Sample, fraction float64, rng *rand.Rand) (train, test parse.Sample) {
+	train, test, err := evaluate.Holdout(sample, fraction, rng)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+
+	return train, test
+}
+
+// loadSample parses a data file, using the schema file when one is named. When
+// lenient, parse errors are printed and the valid examples are kept.
+func loadSample(filename, schemaFile string, lenient bool) parse.Sample {
+	opts := parse.ParseOptions{Lenient: lenient}
+	var sample parse.Sample
+	var err error
+	if schemaFile != "" {
+		schema, schemaErr := parse.SchemaFromFile(schemaFile)
+		if schemaErr != nil {
+			fmt.Printf("reading schema: %v\n", schemaErr)
+			os.Exit(1)
+		}
+		sample, err = parse.FromFileWithSchema(filename, schema, opts)
+	} else {
+		sample, err = parse.FromFileWith(filename, opts)
+	}
+	var parseErrs parse.ParseErrors
+	if errors.As(err, &parseErrs) && lenient && len(sample.Examples) > 0 {
+		fmt.Println(parseErrs.Error())
+		return sample
+	}
+	if err != nil {
+		fmt.Printf("parsing %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+
+	return sample
+}
+
+// outputFilename names a file in the out directory after the input file, with its
+// extension replaced by suffix.
+func outputFilename(filename, suffix string) string {
+	base := strings.TrimSuffix(filepath.Base(filename), path.Ext(filename))
+	return path.Join("out", base+suffix)
+}
+
+func writeJSON(filename string, v interface{}) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Printf("marshalling %s to JSON: %v\n", filename, err)
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(filename, jsonData, 0644); err != nil {
+		fmt.Printf("writing %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+}
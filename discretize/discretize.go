@@ -0,0 +1,442 @@
+// Package discretize converts real attributes into nominal ones by binning their
+// values, so that ID3 can split on them. The bin edges are kept so that examples
+// seen at prediction time are binned the same way as the training examples.
+package discretize
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Method chooses how the bin edges of an attribute are placed.
+type Method string
+
+const (
+	// EqualWidth divides the range of the attribute into bins of the same width.
+	EqualWidth Method = "width"
+	// EqualFrequency places about the same weight of examples in each bin.
+	EqualFrequency Method = "frequency"
+	// MDL recursively splits the values at the edge that best separates the targets,
+	// stopping by the minimum description length criterion of Fayyad and Irani.
+	MDL Method = "mdl"
+)
+
+// DefaultBins is the number of bins used by the unsupervised methods when Options
+// does not give one.
+const DefaultBins = 4
+
+// Options controls how a sample is discretized.
+type Options struct {
+	Method Method
+	// Bins is the number of bins for EqualWidth and EqualFrequency. MDL chooses its own.
+	Bins int
+	// Attributes names the real attributes to discretize. Empty means every real attribute.
+	Attributes []string
+}
+
+// Bins holds the edges dividing one attribute's values. A value belongs to the
+// first bin whose upper edge is greater than it, or to the last bin.
+type Bins struct {
+	Attribute string    `json:"attribute"`
+	Edges     []float64 `json:"edges"`
+}
+
+// Values returns the nominal value of each bin, in order: "<a", "a..b" and so on,
+// ending with ">=z". An attribute with no edges has the single value "all".
+func (b Bins) Values() []string {
+	values := make([]string, len(b.Edges)+1)
+	for i := range values {
+		values[i] = b.label(i)
+	}
+
+	return values
+}
+
+// label returns the nominal value of bin i.
+func (b Bins) label(i int) string {
+	switch {
+	case len(b.Edges) == 0:
+		return "all"
+	case i == 0:
+		return "<" + formatEdge(b.Edges[0])
+	case i == len(b.Edges):
+		return ">=" + formatEdge(b.Edges[i-1])
+	default:
+		return formatEdge(b.Edges[i-1]) + ".." + formatEdge(b.Edges[i])
+	}
+}
+
+func formatEdge(edge float64) string {
+	return strconv.FormatFloat(edge, 'g', -1, 64)
+}
+
+// Value returns the nominal value of the bin that x falls in.
+func (b Bins) Value(x float64) string {
+	return b.label(sort.Search(len(b.Edges), func(i int) bool { return x < b.Edges[i] }))
+}
+
+// Discretizer records the bins of each discretized attribute.
+type Discretizer struct {
+	Bins []Bins `json:"bins"`
+}
+
+// Fit chooses bins for the real attributes of the sample.
+func Fit(sample parse.Sample, opts Options) (*Discretizer, error) {
+	if opts.Method == "" {
+		opts.Method = EqualWidth
+	}
+	if opts.Bins == 0 {
+		opts.Bins = DefaultBins
+	}
+	if opts.Bins < 1 {
+		return nil, fmt.Errorf("discretizing into %d bins", opts.Bins)
+	}
+	if opts.Method == MDL && sample.RealTarget != "" {
+		return nil, fmt.Errorf("MDL binning needs a nominal target, not the real target %s", sample.RealTarget)
+	}
+	selected := make(map[string]bool)
+	for _, name := range opts.Attributes {
+		i, err := sample.AttributeTypes.Index(name)
+		if err != nil {
+			return nil, fmt.Errorf("discretizing %s: %w", name, err)
+		}
+		if !sample.AttributeTypes[i].Real {
+			return nil, fmt.Errorf("discretizing %s: attribute is not real", name)
+		}
+		selected[name] = true
+	}
+	d := &Discretizer{}
+	var iReal int
+	for _, at := range sample.AttributeTypes {
+		if !at.Real {
+			continue
+		}
+		column := iReal
+		iReal++
+		if len(selected) > 0 && !selected[at.Name] {
+			continue
+		}
+		values, err := realValues(sample, column, at.Name)
+		if err != nil {
+			return nil, err
+		}
+		bins := Bins{Attribute: at.Name}
+		switch opts.Method {
+		case EqualWidth:
+			bins.Edges = equalWidthEdges(values, opts.Bins)
+		case EqualFrequency:
+			bins.Edges = equalFrequencyEdges(values, opts.Bins)
+		case MDL:
+			bins.Edges = mdlEdges(values, sample.Targets)
+		default:
+			return nil, fmt.Errorf("unknown discretization method %s, expected %s, %s or %s",
+				opts.Method, EqualWidth, EqualFrequency, MDL)
+		}
+		d.Bins = append(d.Bins, bins)
+	}
+
+	return d, nil
+}
+
+// value is one example's value of a real attribute, with its target and weight.
+type value struct {
+	x      float64
+	target string
+	weight float64
+}
+
+// realValues returns the values of the real attribute at the given real column,
+// sorted ascending.
+func realValues(sample parse.Sample, column int, name string) ([]value, error) {
+	values := make([]value, 0, len(sample.Examples))
+	for i, eg := range sample.Examples {
+		if column >= len(eg.RealValues) {
+			return nil, fmt.Errorf("example %d is missing real value for %s", i, name)
+		}
+		values = append(values, value{x: eg.RealValues[column], target: eg.Target, weight: eg.EffectiveWeight()})
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].x < values[j].x })
+
+	return values, nil
+}
+
+func equalWidthEdges(values []value, bins int) []float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	min, max := values[0].x, values[len(values)-1].x
+	if min == max {
+		return nil
+	}
+	// the extremes are scaled down before they are combined, as the width between
+	// finite values near the largest float64 would overflow
+	lo, hi := min/float64(bins), max/float64(bins)
+	edges := make([]float64, 0, bins-1)
+	for i := 1; i < bins; i++ {
+		edge := round(lo*float64(bins-i) + hi*float64(i))
+		if len(edges) > 0 && edges[len(edges)-1] == edge {
+			continue
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges
+}
+
+// round shortens an edge to six significant digits so that bin names stay readable.
+func round(x float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'g', 6, 64), 64)
+	return rounded
+}
+
+// between returns a readable edge separating the sorted values lo and hi, which
+// differ: their midpoint, rounded when that still lies above lo and no higher than hi.
+func between(lo, hi float64) float64 {
+	mid := lo + (hi-lo)/2
+	if math.IsInf(mid, 0) {
+		// the difference of finite values near the largest float64 can overflow
+		mid = lo/2 + hi/2
+	}
+	if rounded := round(mid); lo < rounded && rounded <= hi {
+		return rounded
+	}
+
+	return mid
+}
+
+// equalFrequencyEdges places an edge midway between the values either side of
+// each bins'th fraction of the total weight. When that falls within a run of equal
+// values the edge moves to the end of the run, so heavily repeated values give
+// fewer, uneven bins.
+func equalFrequencyEdges(values []value, bins int) []float64 {
+	var total float64
+	for _, v := range values {
+		total += v.weight
+	}
+	var edges []float64
+	var cumulative float64
+	next := 1
+	for i := 0; i < len(values)-1 && next < bins; i++ {
+		cumulative += values[i].weight
+		if cumulative < total*float64(next)/float64(bins) || values[i].x == values[i+1].x {
+			continue
+		}
+		edges = append(edges, between(values[i].x, values[i+1].x))
+		for cumulative >= total*float64(next)/float64(bins) {
+			next++
+		}
+	}
+
+	return edges
+}
+
+// mdlEdges splits the sorted values recursively at the boundary that leaves the
+// least weighted target entropy, accepting a split only when its information gain
+// pays for the extra description length it needs (Fayyad and Irani, 1993).
+func mdlEdges(values []value, targets parse.Targets) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+	index := make(map[string]int, len(targets))
+	for i, t := range targets {
+		index[t] = i
+	}
+	total := make([]float64, len(targets))
+	for _, v := range values {
+		total[index[v.target]] += v.weight
+	}
+	left := make([]float64, len(targets))
+	right := make([]float64, len(targets))
+	bestCut := -1
+	bestEntropy := math.Inf(1)
+	var bestLeft, bestRight []float64
+	for i := 0; i < len(values)-1; i++ {
+		left[index[values[i].target]] += values[i].weight
+		if values[i].x == values[i+1].x {
+			continue
+		}
+		for t := range total {
+			right[t] = total[t] - left[t]
+		}
+		wLeft, wRight := sum(left), sum(right)
+		e := (wLeft*entropy(left) + wRight*entropy(right)) / (wLeft + wRight)
+		if e < bestEntropy {
+			bestCut, bestEntropy = i, e
+			bestLeft, bestRight = append([]float64(nil), left...), append([]float64(nil), right...)
+		}
+	}
+	if bestCut < 0 {
+		return nil
+	}
+	n := sum(total)
+	if n <= 1 {
+		return nil
+	}
+	gain := entropy(total) - bestEntropy
+	k, k1, k2 := present(total), present(bestLeft), present(bestRight)
+	delta := math.Log2(math.Pow(3, float64(k))-2) -
+		(float64(k)*entropy(total) - float64(k1)*entropy(bestLeft) - float64(k2)*entropy(bestRight))
+	if gain <= (math.Log2(n-1)+delta)/n {
+		return nil
+	}
+	edges := mdlEdges(values[:bestCut+1], targets)
+	edges = append(edges, between(values[bestCut].x, values[bestCut+1].x))
+
+	return append(edges, mdlEdges(values[bestCut+1:], targets)...)
+}
+
+func sum(counts []float64) float64 {
+	var total float64
+	for _, c := range counts {
+		total += c
+	}
+
+	return total
+}
+
+func present(counts []float64) int {
+	var n int
+	for _, c := range counts {
+		if c > 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// entropy returns the entropy in bits of the target counts, skipping absent targets.
+func entropy(counts []float64) float64 {
+	total := sum(counts)
+	var e float64
+	for _, c := range counts {
+		if c > 0 {
+			p := c / total
+			e -= p * math.Log2(p)
+		}
+	}
+
+	return e
+}
+
+// Apply returns a copy of the sample whose discretized attributes are ordinal, with
+// the examples' real values replaced by the names of their bins.
+func (d *Discretizer) Apply(sample parse.Sample) (parse.Sample, error) {
+	bins := d.byAttribute()
+	result := sample
+	result.AttributeTypes = make(parse.AttributeTypes, len(sample.AttributeTypes))
+	for i, at := range sample.AttributeTypes {
+		if b, ok := bins[at.Name]; ok && at.Real {
+			values := b.Values()
+			at = parse.AttributeType{Name: at.Name, NumValues: len(values), Values: values, Ordinal: true}
+		}
+		result.AttributeTypes[i] = at
+	}
+	result.Examples = make(parse.Examples, len(sample.Examples))
+	for i, eg := range sample.Examples {
+		converted, err := d.apply(sample.AttributeTypes, eg, bins)
+		if err != nil {
+			return parse.Sample{}, fmt.Errorf("discretizing example %d: %w", i, err)
+		}
+		result.Examples[i] = converted
+	}
+
+	return result, nil
+}
+
+// ApplyExample bins the discretized real values of an example laid out according to
+// the given attribute types of the undiscretized sample.
+func (d *Discretizer) ApplyExample(attributeTypes parse.AttributeTypes, eg parse.Example) (parse.Example, error) {
+	return d.apply(attributeTypes, eg, d.byAttribute())
+}
+
+func (d *Discretizer) apply(attributeTypes parse.AttributeTypes, eg parse.Example, bins map[string]Bins) (parse.Example, error) {
+	converted := parse.Example{Target: eg.Target, Weight: eg.Weight, Line: eg.Line}
+	var iString, iReal int
+	for _, at := range attributeTypes {
+		if !at.Real {
+			if iString >= len(eg.StringValues) {
+				return converted, fmt.Errorf("missing value for %s", at.Name)
+			}
+			converted.StringValues = append(converted.StringValues, eg.StringValues[iString])
+			iString++
+			continue
+		}
+		if iReal >= len(eg.RealValues) {
+			return converted, fmt.Errorf("missing real value for %s", at.Name)
+		}
+		x := eg.RealValues[iReal]
+		iReal++
+		if b, ok := bins[at.Name]; ok {
+			converted.StringValues = append(converted.StringValues, b.Value(x))
+		} else {
+			converted.RealValues = append(converted.RealValues, x)
+		}
+	}
+
+	return converted, nil
+}
+
+// Values bins the discretized attributes among values keyed by attribute name, as
+// given to analysis.Node.Classify, leaving other attributes unchanged.
+func (d *Discretizer) Values(values map[string]string) (map[string]string, error) {
+	binned := make(map[string]string, len(values))
+	for name, v := range values {
+		binned[name] = v
+	}
+	for _, b := range d.Bins {
+		v, ok := values[b.Attribute]
+		if !ok {
+			continue
+		}
+		x, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("binning %s: %w", b.Attribute, err)
+		}
+		binned[b.Attribute] = b.Value(x)
+	}
+
+	return binned, nil
+}
+
+func (d *Discretizer) byAttribute() map[string]Bins {
+	bins := make(map[string]Bins, len(d.Bins))
+	for _, b := range d.Bins {
+		bins[b.Attribute] = b
+	}
+
+	return bins
+}
+
+// FromFile reads a discretizer written by Write.
+func FromFile(filename string) (*Discretizer, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	defer file.Close()
+	var d Discretizer
+	if err = json.NewDecoder(file).Decode(&d); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filename, err)
+	}
+	for _, b := range d.Bins {
+		if !sort.Float64sAreSorted(b.Edges) {
+			return nil, fmt.Errorf("decoding %s: edges of %s are not in ascending order", filename, b.Attribute)
+		}
+	}
+
+	return &d, nil
+}
+
+// Write encodes the discretizer's bins as JSON.
+func (d *Discretizer) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
@@ -0,0 +1,199 @@
+package discretize
+
+import (
+	"bytes"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// stepSample has a real attribute x from 0 to 7 whose target changes at 3, a noisy
+// real attribute noise, and a nominal attribute between them.
+func stepSample() parse.Sample {
+	sample := parse.Sample{
+		NumTargets: 2,
+		Targets:    parse.Targets{"low", "high"},
+		AttributeTypes: parse.AttributeTypes{
+			{Name: "x", Real: true},
+			{Name: "colour", NumValues: 2, Values: []string{"red", "blue"}},
+			{Name: "noise", Real: true},
+		},
+	}
+	sample.NumAttributes = len(sample.AttributeTypes)
+	for i := 0; i < 16; i++ {
+		x := float64(i) / 2
+		target := "low"
+		if x >= 3 {
+			target = "high"
+		}
+		colour := "red"
+		if i%2 == 0 {
+			colour = "blue"
+		}
+		sample.Examples = append(sample.Examples, parse.Example{
+			StringValues: []string{colour},
+			RealValues:   []float64{x, float64(i % 3)},
+			Target:       target,
+		})
+	}
+	sample.NumExamples = len(sample.Examples)
+
+	return sample
+}
+
+func TestFit(t *testing.T) {
+	sample := stepSample()
+	tests := []struct {
+		opts     Options
+		expected []Bins
+	}{
+		{Options{Method: EqualWidth, Bins: 4}, []Bins{
+			{Attribute: "x", Edges: []float64{1.875, 3.75, 5.625}},
+			{Attribute: "noise", Edges: []float64{0.5, 1, 1.5}},
+		}},
+		{Options{Method: EqualFrequency, Bins: 4}, []Bins{
+			{Attribute: "x", Edges: []float64{1.75, 3.75, 5.75}},
+			{Attribute: "noise", Edges: []float64{0.5, 1.5}},
+		}},
+		{Options{Method: MDL}, []Bins{
+			{Attribute: "x", Edges: []float64{2.75}},
+			{Attribute: "noise", Edges: nil},
+		}},
+		{Options{Method: MDL, Attributes: []string{"x"}}, []Bins{
+			{Attribute: "x", Edges: []float64{2.75}},
+		}},
+	}
+	for _, test := range tests {
+		d, err := Fit(sample, test.opts)
+		if err != nil {
+			t.Fatalf("fitting %+v: %s", test.opts, err.Error())
+		}
+		if !reflect.DeepEqual(d.Bins, test.expected) {
+			t.Errorf("fitting %+v: expected %v, got %v", test.opts, test.expected, d.Bins)
+		}
+	}
+	for _, opts := range []Options{
+		{Method: "median"},
+		{Bins: -1},
+		{Attributes: []string{"colour"}},
+		{Attributes: []string{"size"}},
+	} {
+		if _, err := Fit(sample, opts); err == nil {
+			t.Errorf("expected error fitting %+v", opts)
+		}
+	}
+}
+
+func TestFit_RealTarget(t *testing.T) {
+	sample := stepSample()
+	sample.NumTargets, sample.Targets, sample.RealTarget = 1, nil, "y"
+	for i := range sample.Examples {
+		sample.Examples[i].Target, sample.Examples[i].Value = "", float64(i)
+	}
+	// MDL separates the targets, which a real target does not have
+	if _, err := Fit(sample, Options{Method: MDL}); err == nil {
+		t.Error("expected error fitting MDL bins with a real target")
+	}
+	d, err := Fit(sample, Options{Method: EqualWidth, Bins: 2})
+	if err != nil {
+		t.Fatalf("fitting equal width bins: %s", err.Error())
+	}
+	if len(d.Bins) != 2 || !reflect.DeepEqual(d.Bins[0].Edges, []float64{3.75}) {
+		t.Errorf("expected x to split at 3.75, got %v", d.Bins)
+	}
+}
+
+func TestFit_Extremes(t *testing.T) {
+	sample := parse.Sample{
+		NumTargets:     2,
+		Targets:        parse.Targets{"low", "high"},
+		NumAttributes:  1,
+		AttributeTypes: parse.AttributeTypes{{Name: "x", Real: true}},
+	}
+	for _, x := range []float64{-1e308, -1, 1, 1e308} {
+		target := "low"
+		if x > 0 {
+			target = "high"
+		}
+		sample.Examples = append(sample.Examples, parse.Example{RealValues: []float64{x}, Target: target})
+	}
+	sample.NumExamples = len(sample.Examples)
+	tests := []struct {
+		opts     Options
+		expected []float64
+	}{
+		{Options{Method: EqualWidth, Bins: 4}, []float64{-5e307, 0, 5e307}},
+		{Options{Method: EqualFrequency, Bins: 2}, []float64{0}},
+		{Options{Method: EqualFrequency, Bins: 4}, []float64{-5e307, 0, 5e307}},
+	}
+	for _, test := range tests {
+		d, err := Fit(sample, test.opts)
+		if err != nil {
+			t.Fatalf("fitting %+v: %s", test.opts, err.Error())
+		}
+		if !reflect.DeepEqual(d.Bins[0].Edges, test.expected) {
+			t.Errorf("fitting %+v: expected edges %v, got %v", test.opts, test.expected, d.Bins[0].Edges)
+		}
+		if err = d.Write(ioutil.Discard); err != nil {
+			t.Errorf("fitting %+v: writing bins: %s", test.opts, err.Error())
+		}
+	}
+}
+
+func TestBins_Value(t *testing.T) {
+	b := Bins{Attribute: "x", Edges: []float64{1.5, 3}}
+	expected := []string{"<1.5", "1.5..3", ">=3"}
+	if !reflect.DeepEqual(b.Values(), expected) {
+		t.Errorf("expected values %v, got %v", expected, b.Values())
+	}
+	for x, value := range map[float64]string{-4: "<1.5", 1.5: "1.5..3", 2.9: "1.5..3", 3: ">=3", 100: ">=3"} {
+		if b.Value(x) != value {
+			t.Errorf("expected %v in bin %s, got %s", x, value, b.Value(x))
+		}
+	}
+	if v := (Bins{}).Value(7); v != "all" {
+		t.Errorf("expected a single bin without edges, got %s", v)
+	}
+}
+
+func TestDiscretizer_Apply(t *testing.T) {
+	sample := stepSample()
+	d, err := Fit(sample, Options{Method: MDL, Attributes: []string{"x"}})
+	if err != nil {
+		t.Fatalf("fitting: %s", err.Error())
+	}
+	discrete, err := d.Apply(sample)
+	if err != nil {
+		t.Fatalf("applying: %s", err.Error())
+	}
+	x := discrete.AttributeTypes[0]
+	if x.Real || !x.Ordinal || !reflect.DeepEqual(x.Values, []string{"<2.75", ">=2.75"}) || !discrete.AttributeTypes[2].Real {
+		t.Errorf("expected only x to become nominal, got %+v", discrete.AttributeTypes)
+	}
+	eg := discrete.Examples[7]
+	if !reflect.DeepEqual(eg.StringValues, []string{">=2.75", "red"}) || !reflect.DeepEqual(eg.RealValues, []float64{1}) {
+		t.Errorf("unexpected discretized example %+v", eg)
+	}
+	if sample.AttributeTypes[0].Real != true || len(sample.Examples[7].RealValues) != 2 {
+		t.Error("applying must not change the original sample")
+	}
+	// discretized samples can be written and parsed again
+	var buf bytes.Buffer
+	if err = parse.Write(&buf, discrete); err != nil {
+		t.Fatalf("writing: %s", err.Error())
+	}
+	if _, err = parse.Parse(&buf); err != nil {
+		t.Fatalf("parsing written sample: %s", err.Error())
+	}
+	values, err := d.Values(map[string]string{"x": "1", "colour": "blue", "noise": "2"})
+	if err != nil {
+		t.Fatalf("binning values: %s", err.Error())
+	}
+	if !reflect.DeepEqual(values, map[string]string{"x": "<2.75", "colour": "blue", "noise": "2"}) {
+		t.Errorf("unexpected binned values %v", values)
+	}
+	if _, err = d.Values(map[string]string{"x": "one"}); err == nil {
+		t.Error("expected error binning a value that is not a number")
+	}
+}
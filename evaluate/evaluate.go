@@ -0,0 +1,321 @@
+// Package evaluate measures how well a tree classifies examples it was not built
+// from.
+package evaluate
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/learner"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math/rand"
+	"strings"
+	"text/tabwriter"
+)
+
+// Confusion totals the weight of examples by actual and predicted target.
+type Confusion struct {
+	Targets parse.Targets
+	// Counts is indexed by the actual target and then the predicted target
+	Counts [][]float64
+	// Unclassified is the weight of each actual target that could not be classified
+	Unclassified []float64
+}
+
+func NewConfusion(targets parse.Targets) *Confusion {
+	c := &Confusion{
+		Targets:      targets,
+		Counts:       make([][]float64, len(targets)),
+		Unclassified: make([]float64, len(targets)),
+	}
+	for i := range c.Counts {
+		c.Counts[i] = make([]float64, len(targets))
+	}
+
+	return c
+}
+
+// Add records a prediction for an example of the actual target.
+func (c *Confusion) Add(actual, predicted string, weight float64) error {
+	iActual, err := c.Targets.Index(actual)
+	if err != nil {
+		return fmt.Errorf("adding actual target: %w", err)
+	}
+	iPredicted, err := c.Targets.Index(predicted)
+	if err != nil {
+		return fmt.Errorf("adding predicted target: %w", err)
+	}
+	c.Counts[iActual][iPredicted] += weight
+
+	return nil
+}
+
+// AddUnclassified records an example of the actual target that had no prediction.
+func (c *Confusion) AddUnclassified(actual string, weight float64) error {
+	iActual, err := c.Targets.Index(actual)
+	if err != nil {
+		return fmt.Errorf("adding actual target: %w", err)
+	}
+	c.Unclassified[iActual] += weight
+
+	return nil
+}
+
+// Total returns the weight of every example recorded, classified or not.
+func (c *Confusion) Total() float64 {
+	var total float64
+	for i := range c.Targets {
+		total += c.actualTotal(i)
+	}
+
+	return total
+}
+
+func (c *Confusion) actualTotal(iActual int) float64 {
+	total := c.Unclassified[iActual]
+	for _, count := range c.Counts[iActual] {
+		total += count
+	}
+
+	return total
+}
+
+// Accuracy returns the fraction of the recorded weight that was predicted correctly.
+func (c *Confusion) Accuracy() float64 {
+	var correct float64
+	for i := range c.Targets {
+		correct += c.Counts[i][i]
+	}
+
+	return ratio(correct, c.Total())
+}
+
+// Recall returns the fraction of the target's examples that were predicted as the target.
+func (c *Confusion) Recall(target string) float64 {
+	i, err := c.Targets.Index(target)
+	if err != nil {
+		return 0
+	}
+
+	return ratio(c.Counts[i][i], c.actualTotal(i))
+}
+
+// Precision returns the fraction of predictions of the target that were correct.
+func (c *Confusion) Precision(target string) float64 {
+	i, err := c.Targets.Index(target)
+	if err != nil {
+		return 0
+	}
+	var predicted float64
+	for iActual := range c.Targets {
+		predicted += c.Counts[iActual][i]
+	}
+
+	return ratio(c.Counts[i][i], predicted)
+}
+
+// Cost returns the total cost of the recorded predictions. Unclassified examples
+// are not counted.
+func (c *Confusion) Cost(costs *analysis.CostMatrix) (float64, error) {
+	var total float64
+	for iActual, actual := range c.Targets {
+		for iPredicted, predicted := range c.Targets {
+			if c.Counts[iActual][iPredicted] == 0 {
+				continue
+			}
+			cost, err := costs.Cost(actual, predicted)
+			if err != nil {
+				return 0, err
+			}
+			total += cost * c.Counts[iActual][iPredicted]
+		}
+	}
+
+	return total, nil
+}
+
+func ratio(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+
+	return numerator / denominator
+}
+
+// String renders the matrix with a row per actual target, followed by the recall
+// and precision of each target and the overall accuracy.
+func (c *Confusion) String() string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "actual \\ predicted\t")
+	for _, t := range c.Targets {
+		fmt.Fprintf(tw, "%s\t", t)
+	}
+	fmt.Fprint(tw, "unclassified\trecall\tprecision\t\n")
+	for i, t := range c.Targets {
+		fmt.Fprintf(tw, "%s\t", t)
+		for _, count := range c.Counts[i] {
+			fmt.Fprintf(tw, "%g\t", count)
+		}
+		fmt.Fprintf(tw, "%g\t%.3f\t%.3f\t\n", c.Unclassified[i], c.Recall(t), c.Precision(t))
+	}
+	tw.Flush()
+	fmt.Fprintf(&sb, "accuracy: %.3f of %g\n", c.Accuracy(), c.Total())
+
+	return sb.String()
+}
+
+// Tree classifies every example of the sample with the tree. Examples the tree has
+// no branch for are recorded as unclassified.
+func Tree(tree analysis.Node, sample parse.Sample) (*Confusion, error) {
+	return TreeWithCosts(tree, sample, nil)
+}
+
+// TreeWithCosts classifies every example as Tree does, predicting the target of least
+// expected cost at each leaf when costs are given.
+func TreeWithCosts(tree analysis.Node, sample parse.Sample, costs *analysis.CostMatrix) (*Confusion, error) {
+	return classifyAll(sample, func(eg parse.Example) (string, error) {
+		if costs == nil {
+			return tree.ClassifyExample(sample.AttributeTypes, eg)
+		}
+		return tree.ClassifyExampleWithCosts(sample.AttributeTypes, eg, costs)
+	})
+}
+
+// Classifier classifies every example of the sample with the classifier, recording
+// examples it returns an error for, such as ErrUnseenValue, as unclassified.
+func Classifier(classifier analysis.Classifier, sample parse.Sample) (*Confusion, error) {
+	return classifyAll(sample, func(eg parse.Example) (string, error) {
+		return classifier.ClassifyExample(sample.AttributeTypes, eg)
+	})
+}
+
+// ExampleClassifier is a model that classifies parsed examples, such as an ensemble.
+type ExampleClassifier interface {
+	ClassifyExample(attributeTypes parse.AttributeTypes, eg parse.Example) (string, error)
+}
+
+// Model classifies every example of the sample with the model, recording examples
+// it returns an error for as unclassified.
+func Model(model ExampleClassifier, sample parse.Sample) (*Confusion, error) {
+	return classifyAll(sample, func(eg parse.Example) (string, error) {
+		return model.ClassifyExample(sample.AttributeTypes, eg)
+	})
+}
+
+// Learner fits the learner to the training sample and classifies every example of
+// the test sample with the result, recording examples it returns an error for as
+// unclassified.
+func Learner(ctx context.Context, l learner.Learner, train, test parse.Sample) (*Confusion, error) {
+	c, err := l.Fit(ctx, train)
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyAll(test, func(eg parse.Example) (string, error) {
+		return learner.ClassifyExample(c, test.AttributeTypes, eg)
+	})
+}
+
+func classifyAll(sample parse.Sample, classify func(eg parse.Example) (string, error)) (*Confusion, error) {
+	c := NewConfusion(sample.Targets)
+	for i, eg := range sample.Examples {
+		predicted, err := classify(eg)
+		if err != nil {
+			if err = c.AddUnclassified(eg.Target, eg.EffectiveWeight()); err != nil {
+				return c, fmt.Errorf("evaluating example %d: %w", i, err)
+			}
+			continue
+		}
+		if err = c.Add(eg.Target, predicted, eg.EffectiveWeight()); err != nil {
+			return c, fmt.Errorf("evaluating example %d: %w", i, err)
+		}
+	}
+
+	return c, nil
+}
+
+// Holdout splits the sample into a training sample and a test sample holding about
+// testFraction of the examples of each target, or of all the examples of a sample
+// with a real target. The fraction must be at least 0 and less than 1.
+func Holdout(sample parse.Sample, testFraction float64, rng *rand.Rand) (train, test parse.Sample, err error) {
+	if !(testFraction >= 0 && testFraction < 1) {
+		return train, test, fmt.Errorf("holding out a fraction of %v, expected at least 0 and less than 1", testFraction)
+	}
+	isTest := make([]bool, len(sample.Examples))
+	for _, indexes := range shuffledStrata(sample, rng) {
+		numTest := int(float64(len(indexes))*testFraction + 0.5)
+		for _, i := range indexes[:numTest] {
+			isTest[i] = true
+		}
+	}
+
+	train, test = split(sample, func(i int) bool { return isTest[i] })
+
+	return train, test, nil
+}
+
+// KFold splits the sample into k folds holding about the same share of the examples
+// of each target, or of all the examples of a sample with a real target. It returns
+// for each fold a training sample of the examples of the other folds and a test
+// sample of the fold's own examples, so that every example is tested once.
+func KFold(sample parse.Sample, k int, rng *rand.Rand) (trains, tests []parse.Sample, err error) {
+	if k < 2 || k > len(sample.Examples) {
+		return nil, nil, fmt.Errorf("cannot split %d examples into %d folds", len(sample.Examples), k)
+	}
+	fold := make([]int, len(sample.Examples))
+	// dealing continues from one target to the next so that the folds differ in size
+	// by at most one example
+	var dealt int
+	for _, indexes := range shuffledStrata(sample, rng) {
+		for _, i := range indexes {
+			fold[i] = dealt % k
+			dealt++
+		}
+	}
+	trains, tests = make([]parse.Sample, k), make([]parse.Sample, k)
+	for f := range tests {
+		trains[f], tests[f] = split(sample, func(i int) bool { return fold[i] == f })
+	}
+
+	return trains, tests, nil
+}
+
+// shuffledStrata groups the indexes of the sample's examples by target, in declared
+// order so that the groups only depend on the seed, and shuffles each group.
+func shuffledStrata(sample parse.Sample, rng *rand.Rand) [][]int {
+	byTarget := make(map[string][]int)
+	for i, eg := range sample.Examples {
+		byTarget[eg.Target] = append(byTarget[eg.Target], i)
+	}
+	targets := sample.Targets
+	if sample.RealTarget != "" {
+		// the examples of a real target, whose Target is empty, are split as one group
+		targets = parse.Targets{""}
+	}
+	strata := make([][]int, len(targets))
+	for s, t := range targets {
+		indexes := byTarget[t]
+		rng.Shuffle(len(indexes), func(i, j int) { indexes[i], indexes[j] = indexes[j], indexes[i] })
+		strata[s] = indexes
+	}
+
+	return strata
+}
+
+// split divides the sample's examples between a training sample and a test sample,
+// keeping their order.
+func split(sample parse.Sample, isTest func(i int) bool) (train, test parse.Sample) {
+	train, test = sample, sample
+	train.Examples, test.Examples = nil, nil
+	for i, eg := range sample.Examples {
+		if isTest(i) {
+			test.Examples = append(test.Examples, eg)
+		} else {
+			train.Examples = append(train.Examples, eg)
+		}
+	}
+	train.NumExamples, test.NumExamples = len(train.Examples), len(test.Examples)
+
+	return train, test
+}
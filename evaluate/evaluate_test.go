@@ -0,0 +1,169 @@
+package evaluate
+
+import (
+	"context"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/learner"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfusion(t *testing.T) {
+	c := NewConfusion(parse.Targets{"yes", "no"})
+	c.Add("yes", "yes", 3)
+	c.Add("yes", "no", 1)
+	c.Add("no", "no", 4)
+	c.Add("no", "yes", 1)
+	c.AddUnclassified("no", 1)
+	if err := c.Add("maybe", "yes", 1); err == nil {
+		t.Error("expected error for unknown target")
+	}
+	if c.Total() != 10 {
+		t.Errorf("expected total 10, got %v", c.Total())
+	}
+	if c.Accuracy() != 0.7 {
+		t.Errorf("expected accuracy 0.7, got %v", c.Accuracy())
+	}
+	if c.Recall("yes") != 0.75 || c.Recall("no") != 4.0/6 {
+		t.Errorf("unexpected recall %v and %v", c.Recall("yes"), c.Recall("no"))
+	}
+	if c.Precision("yes") != 0.75 || c.Precision("no") != 0.8 {
+		t.Errorf("unexpected precision %v and %v", c.Precision("yes"), c.Precision("no"))
+	}
+	costs := analysis.NewCostMatrix(c.Targets)
+	costs.Set("yes", "no", 5)
+	if cost, err := c.Cost(costs); err != nil || cost != 6 {
+		t.Errorf("expected cost 6, got %v (%v)", cost, err)
+	}
+	if !strings.Contains(c.String(), "accuracy: 0.700 of 10") {
+		t.Errorf("unexpected report:\n%s", c.String())
+	}
+}
+
+func TestTree(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	tree, err := analysis.BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	c, err := Tree(tree, sample)
+	if err != nil {
+		t.Fatalf("evaluating tree: %s", err.Error())
+	}
+	if c.Accuracy() != 1 {
+		t.Errorf("expected the training data to be classified perfectly, got %v", c.Accuracy())
+	}
+	tree.Children = tree.Children[:1]
+	var kept float64
+	for _, count := range tree.Children[0].TargetCounts {
+		kept += count
+	}
+	c, err = Tree(tree, sample)
+	if err != nil {
+		t.Fatalf("evaluating pruned tree: %s", err.Error())
+	}
+	var unclassified float64
+	for _, u := range c.Unclassified {
+		unclassified += u
+	}
+	if unclassified != c.Total()-kept {
+		t.Errorf("expected the %v examples without a branch to be unclassified, got %v", c.Total()-kept, unclassified)
+	}
+	c, err = Classifier(analysis.Classifier{Tree: tree, Unseen: analysis.UnseenParentMajority}, sample)
+	if err != nil {
+		t.Fatalf("evaluating classifier: %s", err.Error())
+	}
+	for _, u := range c.Unclassified {
+		if u != 0 {
+			t.Errorf("expected every example to be classified by the parent majority, got %v", c.Unclassified)
+			break
+		}
+	}
+	m, err := Model(analysis.Classifier{Tree: tree, Unseen: analysis.UnseenParentMajority}, sample)
+	if err != nil || m.Accuracy() != c.Accuracy() {
+		t.Errorf("expected any example classifier to score as Classifier does, got %v (%v)", m, err)
+	}
+}
+
+func TestLearner(t *testing.T) {
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	train, test, err := Holdout(sample, 0.25, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("holding out: %v", err)
+	}
+	c, err := Learner(context.Background(), learner.ZeroR{}, train, test)
+	if err != nil {
+		t.Fatalf("evaluating learner: %v", err)
+	}
+	// ZeroR predicts none, which 4 of the 6 test examples are
+	if c.Accuracy() != 4.0/6 || c.Recall("none") != 1 {
+		t.Errorf("expected every example to be predicted none, got\n%s", c.String())
+	}
+}
+
+func TestHoldout(t *testing.T) {
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	train, test, err := Holdout(sample, 0.25, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("holding out: %v", err)
+	}
+	if len(train.Examples)+len(test.Examples) != 24 || test.NumExamples != len(test.Examples) {
+		t.Fatalf("expected 24 examples split in two, got %d and %d", len(train.Examples), len(test.Examples))
+	}
+	// 15 none, 5 soft and 4 hard examples
+	counts := make(map[string]int)
+	for _, eg := range test.Examples {
+		counts[eg.Target]++
+	}
+	if counts["none"] != 4 || counts["soft"] != 1 || counts["hard"] != 1 {
+		t.Errorf("expected a stratified test sample, got %v", counts)
+	}
+	for _, fraction := range []float64{-0.5, 1, 1.5} {
+		if _, _, err = Holdout(sample, fraction, rand.New(rand.NewSource(1))); err == nil {
+			t.Errorf("expected an error holding out a fraction of %v", fraction)
+		}
+	}
+}
+
+func TestKFold(t *testing.T) {
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	trains, tests, err := KFold(sample, 5, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("splitting: %v", err)
+	}
+	var tested int
+	for f := range tests {
+		tested += len(tests[f].Examples)
+		if len(trains[f].Examples)+len(tests[f].Examples) != 24 {
+			t.Errorf("expected fold %d to split all 24 examples, got %d and %d", f, len(trains[f].Examples), len(tests[f].Examples))
+		}
+		// 24 examples dealt to 5 folds leave 4 or 5 in each
+		if n := len(tests[f].Examples); n < 4 || n > 5 {
+			t.Errorf("expected fold %d to test 4 or 5 examples, got %d", f, n)
+		}
+	}
+	if tested != 24 {
+		t.Errorf("expected every example to be tested once, got %d tests", tested)
+	}
+	if _, _, err = KFold(sample, 1, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("expected an error for a single fold")
+	}
+}
@@ -0,0 +1,107 @@
+package evaluate
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"math/rand"
+)
+
+// Importance is an attribute's importance by the gain of its splits in a tree and by
+// how much worse the tree scores a holdout sample when the attribute's values are
+// shuffled among the examples.
+type Importance struct {
+	analysis.Importance
+	// Permutation is the mean fall in accuracy, or for a regression tree the mean
+	// rise in RMSE, when the attribute's values are shuffled
+	Permutation float64
+	// PermutationStdDev is the standard deviation of the fall over the repeats
+	PermutationStdDev float64
+}
+
+// PermutationImportance returns the tree's Node.Importance, in the same order,
+// adding to each attribute how much worse the tree scores the holdout sample when
+// the attribute's values are shuffled among its examples, repeating the shuffle the
+// given number of times. Examples the tree cannot classify count as errors, and are
+// left out of the RMSE of a regression tree.
+func PermutationImportance(tree analysis.Node, holdout parse.Sample, repeats int, rng *rand.Rand) ([]Importance, error) {
+	if repeats < 1 {
+		return nil, fmt.Errorf("repeats %d must be at least one", repeats)
+	}
+	if len(holdout.Examples) == 0 {
+		return nil, fmt.Errorf("holdout sample has no examples")
+	}
+	if holdout.RealTarget != "" && tree.Summary == nil {
+		return nil, fmt.Errorf("tree was not built to predict a real target")
+	}
+	values := make([]map[string]string, len(holdout.Examples))
+	for i, eg := range holdout.Examples {
+		v, err := holdout.AttributeTypes.ExampleValues(eg)
+		if err != nil {
+			return nil, fmt.Errorf("reading holdout example %d: %w", i, err)
+		}
+		values[i] = v
+	}
+	baseline := permutedScore(tree, holdout, values)
+
+	var importances []Importance
+	for _, gain := range tree.Importance() {
+		imp := Importance{Importance: gain}
+		original := make([]string, len(values))
+		for i, v := range values {
+			original[i] = v[gain.Attribute]
+		}
+		losses := make([]float64, repeats)
+		for r := range losses {
+			shuffled := make([]string, len(original))
+			copy(shuffled, original)
+			rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			for i, v := range values {
+				v[gain.Attribute] = shuffled[i]
+			}
+			losses[r] = baseline - permutedScore(tree, holdout, values)
+		}
+		for i, v := range values {
+			v[gain.Attribute] = original[i]
+		}
+		for _, loss := range losses {
+			imp.Permutation += loss
+		}
+		imp.Permutation /= float64(repeats)
+		for _, loss := range losses {
+			imp.PermutationStdDev += (loss - imp.Permutation) * (loss - imp.Permutation)
+		}
+		imp.PermutationStdDev = math.Sqrt(imp.PermutationStdDev / float64(repeats))
+		importances = append(importances, imp)
+	}
+
+	return importances, nil
+}
+
+// permutedScore returns the accuracy of the tree on the holdout examples with the
+// given values, or the negated RMSE of a regression tree so that higher is better
+// either way.
+func permutedScore(tree analysis.Node, holdout parse.Sample, values []map[string]string) float64 {
+	if holdout.RealTarget != "" {
+		var r Residuals
+		for i, eg := range holdout.Examples {
+			predicted, err := tree.Predict(values[i])
+			if err != nil {
+				r.AddUnpredicted(eg.EffectiveWeight())
+				continue
+			}
+			r.Add(eg.Value, predicted, eg.EffectiveWeight())
+		}
+		return -r.RMSE()
+	}
+	var correct, total float64
+	for i, eg := range holdout.Examples {
+		total += eg.EffectiveWeight()
+		if label, err := tree.Classify(values[i]); err == nil && label == eg.Target {
+			correct += eg.EffectiveWeight()
+		}
+	}
+
+	return ratio(correct, total)
+}
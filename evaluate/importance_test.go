@@ -0,0 +1,54 @@
+package evaluate
+
+import (
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestPermutationImportance(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	tree, err := analysis.BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %v", err)
+	}
+	importances, err := PermutationImportance(tree, sample, 10, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("measuring importance: %v", err)
+	}
+	if len(importances) != 4 {
+		t.Fatalf("expected an importance for each of 4 attributes, got %v", importances)
+	}
+	for i, imp := range importances {
+		if imp.Importance != tree.Importance()[i] {
+			t.Errorf("expected the gain importance in the same order, got %v", imp.Importance)
+		}
+		// the tree classifies its training examples perfectly, so shuffling cannot help
+		if imp.Permutation < 0 || imp.PermutationStdDev < 0 {
+			t.Errorf("expected no gain from shuffling %s, got %v", imp.Attribute, imp.Permutation)
+		}
+	}
+	// every example with reduced tears is none, so shuffling tear-rate costs the most
+	var tearRate Importance
+	for _, imp := range importances {
+		if imp.Attribute == "tear-rate" {
+			tearRate = imp
+		}
+	}
+	for _, imp := range importances {
+		if imp.Permutation > tearRate.Permutation {
+			t.Errorf("expected shuffling tear-rate to cost the most, but %s costs %v", imp.Attribute, imp.Permutation)
+		}
+	}
+	if _, err = PermutationImportance(tree, sample, 0, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("expected an error for no repeats")
+	}
+}
@@ -0,0 +1,95 @@
+package evaluate
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"strings"
+)
+
+// Residuals accumulates the differences between the real targets of examples and
+// the values a regression tree predicts for them.
+type Residuals struct {
+	// Total is the weight of the predicted examples
+	Total float64
+	// Unpredicted is the weight of the examples the tree has no branch for
+	Unpredicted float64
+	sumSquares  float64
+	sumAbsolute float64
+	// sumActual and sumActualSquares give the spread of the actual values for R²
+	sumActual        float64
+	sumActualSquares float64
+}
+
+// Add records a prediction for an example with the given actual value and weight.
+func (r *Residuals) Add(actual, predicted, weight float64) {
+	residual := actual - predicted
+	r.Total += weight
+	r.sumSquares += weight * residual * residual
+	r.sumAbsolute += weight * math.Abs(residual)
+	r.sumActual += weight * actual
+	r.sumActualSquares += weight * actual * actual
+}
+
+// AddUnpredicted records an example that could not be predicted.
+func (r *Residuals) AddUnpredicted(weight float64) {
+	r.Unpredicted += weight
+}
+
+// RMSE returns the root of the weighted mean squared residual.
+func (r *Residuals) RMSE() float64 {
+	return math.Sqrt(ratio(r.sumSquares, r.Total))
+}
+
+// MAE returns the weighted mean absolute residual.
+func (r *Residuals) MAE() float64 {
+	return ratio(r.sumAbsolute, r.Total)
+}
+
+// R2 returns the coefficient of determination, the fraction of the variance of the
+// actual values that the predictions explain. It is zero when the actual values
+// do not vary, and negative when predicting their mean would have done better.
+func (r *Residuals) R2() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	mean := r.sumActual / r.Total
+	totalSquares := r.sumActualSquares - r.Total*mean*mean
+	if totalSquares <= 0 {
+		return 0
+	}
+
+	return 1 - r.sumSquares/totalSquares
+}
+
+// String renders the error measures and the weight of the examples they cover.
+func (r *Residuals) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "rmse: %.4g\nmae: %.4g\nr²: %.3f\n", r.RMSE(), r.MAE(), r.R2())
+	fmt.Fprintf(&sb, "predicted %g, unpredicted %g\n", r.Total, r.Unpredicted)
+
+	return sb.String()
+}
+
+// Regression predicts the real target of every example of the sample with the
+// tree. Examples the tree has no branch for are recorded as unpredicted.
+func Regression(tree analysis.Node, sample parse.Sample) (*Residuals, error) {
+	if sample.RealTarget == "" {
+		return nil, fmt.Errorf("sample has no real target to predict")
+	}
+	if tree.Summary == nil {
+		return nil, fmt.Errorf("tree was not built to predict a real target")
+	}
+	var r Residuals
+	for _, eg := range sample.Examples {
+		predicted, err := tree.PredictExample(sample.AttributeTypes, eg)
+		if err != nil {
+			r.AddUnpredicted(eg.EffectiveWeight())
+			continue
+		}
+		r.Add(eg.Value, predicted, eg.EffectiveWeight())
+	}
+
+	return &r, nil
+}
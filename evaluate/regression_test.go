@@ -0,0 +1,61 @@
+package evaluate
+
+import (
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestResiduals(t *testing.T) {
+	var r Residuals
+	r.Add(1, 2, 1)
+	r.Add(3, 3, 1)
+	r.Add(5, 2, 2)
+	r.AddUnpredicted(1)
+	// residuals of -1, 0 and 3, the last counting twice
+	if r.Total != 4 || r.Unpredicted != 1 {
+		t.Errorf("expected 4 predicted and 1 unpredicted, got %v and %v", r.Total, r.Unpredicted)
+	}
+	if math.Abs(r.RMSE()-math.Sqrt(19.0/4)) > 1e-12 || r.MAE() != 7.0/4 {
+		t.Errorf("expected rmse %v and mae %v, got %v and %v", math.Sqrt(19.0/4), 7.0/4, r.RMSE(), r.MAE())
+	}
+	// the actual values 1, 3, 5 and 5 vary by 11 about their mean of 3.5
+	if math.Abs(r.R2()-(1-19.0/11)) > 1e-12 {
+		t.Errorf("expected r² %v, got %v", 1-19.0/11, r.R2())
+	}
+}
+
+func TestRegression(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	schema, err := parse.SchemaFromFile("../data/fishing-catch.schema.json")
+	if err != nil {
+		t.Fatalf("reading schema: %s", err.Error())
+	}
+	sample, err := parse.FromFileWithSchema("../data/fishing-catch.csv", schema, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed parsing file fishing-catch.csv: %v", err)
+	}
+	tree, err := analysis.BuildTree(sample)
+	if err != nil {
+		t.Fatalf("building tree: %s", err.Error())
+	}
+	r, err := Regression(tree, sample)
+	if err != nil {
+		t.Fatalf("evaluating tree: %s", err.Error())
+	}
+	if r.Total != float64(len(sample.Examples)) || r.R2() < 0.9 {
+		t.Errorf("expected the training data to be predicted closely, got r² %v over %v", r.R2(), r.Total)
+	}
+	train, test, err := Holdout(sample, 0.3, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("holding out: %v", err)
+	}
+	if len(test.Examples) != 9 || len(train.Examples) != 21 {
+		t.Errorf("expected 9 of 30 examples held out, got %d", len(test.Examples))
+	}
+}
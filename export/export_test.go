@@ -0,0 +1,209 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var dataFiles = []string{
+	"../data/contact-lenses.data.txt",
+	"../data/fishing.data.txt",
+	"../data/new-treatment.data.txt",
+}
+
+// variant changes how the trees of the data files are built, so that every kind of
+// split is exported
+type variant struct {
+	// ordinal treats every attribute with more than two values as ordinal
+	ordinal      bool
+	binarySplits bool
+}
+
+var variants = []variant{{}, {ordinal: true}, {binarySplits: true}}
+
+func buildTree(filename string, v variant, t *testing.T) (parse.Sample, analysis.Node) {
+	sample, err := parse.FromFile(filename)
+	if err != nil {
+		t.Fatalf("failed parsing file %s: %v", filename, err)
+	}
+	for i := range sample.AttributeTypes {
+		sample.AttributeTypes[i].Ordinal = v.ordinal && len(sample.AttributeTypes[i].Values) > 2
+	}
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	tree, err := analysis.BuildTreeContext(context.Background(), sample, analysis.Options{BinarySplits: v.binarySplits})
+	if err != nil {
+		t.Fatalf("building tree for %s: %s", filename, err.Error())
+	}
+
+	return sample, tree
+}
+
+func expectedLabels(sample parse.Sample, tree analysis.Node, t *testing.T) []string {
+	labels := make([]string, len(sample.Examples))
+	for i, eg := range sample.Examples {
+		label, err := tree.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example %d: %s", i, err.Error())
+		}
+		labels[i] = label
+	}
+
+	return labels
+}
+
+func TestSQL_Quoting(t *testing.T) {
+	if q := ANSI.quoteIdentifier(`a"b`); q != `"a""b"` {
+		t.Errorf("ANSI quoting: got %s", q)
+	}
+	if q := MySQL.quoteIdentifier("a`b"); q != "`a``b`" {
+		t.Errorf("MySQL quoting: got %s", q)
+	}
+	if q := SQLServer.quoteIdentifier("a]b"); q != "[a]]b]" {
+		t.Errorf("SQL Server quoting: got %s", q)
+	}
+	if q := quoteString("it's"); q != "'it''s'" {
+		t.Errorf("string quoting: got %s", q)
+	}
+}
+
+func TestSQL_UnknownAttribute(t *testing.T) {
+	_, tree := buildTree("../data/new-treatment.data.txt", variant{}, t)
+	if _, err := SQL(tree, parse.AttributeTypes{}, SQLOptions{}); err == nil {
+		t.Error("expected error for attribute missing from attribute types")
+	}
+}
+
+func TestSQL_Columns(t *testing.T) {
+	sample, tree := buildTree("../data/new-treatment.data.txt", variant{}, t)
+	expr, err := SQL(tree, sample.AttributeTypes, SQLOptions{
+		Dialect: MySQL,
+		Columns: map[string]string{"bp": "blood_pressure"},
+	})
+	if err != nil {
+		t.Fatalf("writing SQL: %s", err.Error())
+	}
+	expected := "CASE WHEN `blood_pressure` = 'normal' THEN 'pos' WHEN `blood_pressure` = 'high' THEN 'neg' ELSE NULL END"
+	if expr != expected {
+		t.Errorf("expected %s, got %s", expected, expr)
+	}
+}
+
+// TestSQL_RoundTrip evaluates the generated expression with sqlite3 against
+// every example and compares the result with the Go classifier.
+func TestSQL_RoundTrip(t *testing.T) {
+	sqlite, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 not found in PATH")
+	}
+	for _, filename := range dataFiles {
+		for _, v := range variants {
+			sample, tree := buildTree(filename, v, t)
+			expr, err := SQL(tree, sample.AttributeTypes, SQLOptions{Indent: "  "})
+			if err != nil {
+				t.Fatalf("writing SQL for %s: %s", filename, err.Error())
+			}
+			var script strings.Builder
+			var columns []string
+			for _, at := range sample.AttributeTypes {
+				columns = append(columns, ANSI.quoteIdentifier(at.Name)+" TEXT")
+			}
+			fmt.Fprintf(&script, "CREATE TABLE rows (id INTEGER, %s);\n", strings.Join(columns, ", "))
+			for i, eg := range sample.Examples {
+				var values []string
+				for _, v := range eg.StringValues {
+					values = append(values, quoteString(v))
+				}
+				fmt.Fprintf(&script, "INSERT INTO rows VALUES (%d, %s);\n", i, strings.Join(values, ", "))
+			}
+			fmt.Fprintf(&script, "SELECT %s FROM rows ORDER BY id;\n", expr)
+
+			cmd := exec.Command(sqlite, ":memory:")
+			cmd.Stdin = strings.NewReader(script.String())
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("running sqlite3 for %s: %v\n%s", filename, err, out)
+			}
+			got := strings.Split(strings.TrimSpace(string(out)), "\n")
+			expected := expectedLabels(sample, tree, t)
+			if strings.Join(got, ",") != strings.Join(expected, ",") {
+				t.Errorf("%s %+v: expected %v, got %v", filename, v, expected, got)
+			}
+		}
+	}
+}
+
+// TestJavaScript_RoundTrip evaluates the generated function with node against
+// every example and compares the result with the Go classifier.
+func TestJavaScript_RoundTrip(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found in PATH")
+	}
+	for _, filename := range dataFiles {
+		for _, v := range variants {
+			sample, tree := buildTree(filename, v, t)
+			fn, err := JavaScript(tree, sample.AttributeTypes, JavaScriptOptions{FunctionName: "predict"})
+			if err != nil {
+				t.Fatalf("writing JavaScript for %s: %s", filename, err.Error())
+			}
+			var rows []map[string]string
+			for _, eg := range sample.Examples {
+				values, err := sample.AttributeTypes.ExampleValues(eg)
+				if err != nil {
+					t.Fatalf("getting example values: %s", err.Error())
+				}
+				rows = append(rows, values)
+			}
+			rowsJSON, err := json.Marshal(rows)
+			if err != nil {
+				t.Fatalf("marshalling rows: %v", err)
+			}
+			script := fn + "console.log(JSON.stringify(" + string(rowsJSON) + ".map(predict)));\n"
+
+			cmd := exec.Command(node)
+			cmd.Stdin = strings.NewReader(script)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("running node for %s: %v\n%s", filename, err, out)
+			}
+			var got []string
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatalf("reading node output %s: %v", out, err)
+			}
+			expected := expectedLabels(sample, tree, t)
+			if strings.Join(got, ",") != strings.Join(expected, ",") {
+				t.Errorf("%s %+v: expected %v, got %v", filename, v, expected, got)
+			}
+		}
+	}
+}
+
+func TestJavaScript_UnknownValue(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found in PATH")
+	}
+	sample, tree := buildTree("../data/new-treatment.data.txt", variant{}, t)
+	fn, err := JavaScript(tree, sample.AttributeTypes, JavaScriptOptions{})
+	if err != nil {
+		t.Fatalf("writing JavaScript: %s", err.Error())
+	}
+	cmd := exec.Command(node)
+	cmd.Stdin = strings.NewReader(fn + `console.log(JSON.stringify(classify({bp: "low"})));`)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("running node: %v\n%s", err, out)
+	}
+	if strings.TrimSpace(string(out)) != "null" {
+		t.Errorf("expected null for unseen value, got %s", out)
+	}
+}
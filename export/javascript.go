@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"strings"
+)
+
+// JavaScriptOptions controls the JavaScript emitter.
+type JavaScriptOptions struct {
+	// FunctionName is the name of the emitted function, defaulting to classify.
+	FunctionName string
+}
+
+// JavaScript translates a tree into a self-contained function that takes an
+// object keyed by attribute name and returns the predicted target, or null when
+// a value has no branch.
+func JavaScript(root analysis.Node, attributeTypes parse.AttributeTypes, opts JavaScriptOptions) (string, error) {
+	name := opts.FunctionName
+	if name == "" {
+		name = "classify"
+	}
+	var sb strings.Builder
+	sb.WriteString("function ")
+	sb.WriteString(name)
+	sb.WriteString("(row) {\n")
+	if err := writeJSNode(&sb, root, attributeTypes, 1); err != nil {
+		return "", fmt.Errorf("writing JavaScript for tree: %w", err)
+	}
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
+func writeJSNode(sb *strings.Builder, node analysis.Node, attributeTypes parse.AttributeTypes, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	if node.Terminal {
+		sb.WriteString(indent)
+		sb.WriteString("return ")
+		sb.WriteString(jsString(node.Label))
+		sb.WriteString(";\n")
+		return nil
+	}
+	if !attributeTypes.IsValid(node.Label) {
+		return fmt.Errorf("attribute %s of node is not a known attribute type", node.Label)
+	}
+	sb.WriteString(indent)
+	sb.WriteString("switch (row[")
+	sb.WriteString(jsString(node.Label))
+	sb.WriteString("]) {\n")
+	for _, child := range node.Children {
+		values := []string{child.FilterValue}
+		if child.FilterOp != "" {
+			// a range of ordinal values is one case per value
+			values = child.FilterValues
+		}
+		for _, v := range values {
+			sb.WriteString(indent)
+			sb.WriteString("case ")
+			sb.WriteString(jsString(v))
+			sb.WriteString(":\n")
+		}
+		if err := writeJSNode(sb, child, attributeTypes, depth+1); err != nil {
+			return fmt.Errorf("branch %s of %s: %w", child.FilterValue, node.Label, err)
+		}
+	}
+	sb.WriteString(indent)
+	sb.WriteString("default:\n")
+	sb.WriteString(indent)
+	sb.WriteString("  return null;\n")
+	sb.WriteString(indent)
+	sb.WriteString("}\n")
+
+	return nil
+}
+
+// jsString returns a JavaScript string literal. JSON string encoding is a subset
+// of JavaScript string literal syntax.
+func jsString(value string) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"strings"
+)
+
+// Dialect selects how identifiers are quoted in generated SQL.
+type Dialect int
+
+const (
+	// ANSI quotes identifiers with double quotes, as used by PostgreSQL, SQLite and Oracle.
+	ANSI Dialect = iota
+	// MySQL quotes identifiers with backticks.
+	MySQL
+	// SQLServer quotes identifiers with square brackets.
+	SQLServer
+)
+
+func (d Dialect) quoteIdentifier(name string) string {
+	switch d {
+	case MySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case SQLServer:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// SQLOptions controls the SQL emitter.
+type SQLOptions struct {
+	Dialect Dialect
+	// Columns maps attribute names to column names. Attributes not in the map
+	// use their own name as the column name.
+	Columns map[string]string
+	// Indent is written once per nesting level. When empty, the expression is
+	// written on a single line.
+	Indent string
+}
+
+// SQL translates a tree into a CASE WHEN ... THEN ... END expression over the
+// columns named by the attribute types. Rows with a value that has no branch
+// evaluate to NULL.
+func SQL(root analysis.Node, attributeTypes parse.AttributeTypes, opts SQLOptions) (string, error) {
+	var sb strings.Builder
+	if err := writeSQLNode(&sb, root, attributeTypes, opts, 0); err != nil {
+		return "", fmt.Errorf("writing SQL for tree: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+func writeSQLNode(sb *strings.Builder, node analysis.Node, attributeTypes parse.AttributeTypes, opts SQLOptions, depth int) error {
+	if node.Terminal {
+		sb.WriteString(quoteString(node.Label))
+		return nil
+	}
+	if !attributeTypes.IsValid(node.Label) {
+		return fmt.Errorf("attribute %s of node is not a known attribute type", node.Label)
+	}
+	column := node.Label
+	if name, ok := opts.Columns[node.Label]; ok {
+		column = name
+	}
+	column = opts.Dialect.quoteIdentifier(column)
+
+	sb.WriteString("CASE")
+	for _, child := range node.Children {
+		newline(sb, opts.Indent, depth+1)
+		sb.WriteString("WHEN ")
+		sb.WriteString(column)
+		if child.FilterOp == "" {
+			sb.WriteString(" = ")
+			sb.WriteString(quoteString(child.FilterValue))
+		} else {
+			// string columns have no order, so a range of ordinal values is listed
+			quoted := make([]string, len(child.FilterValues))
+			for i, v := range child.FilterValues {
+				quoted[i] = quoteString(v)
+			}
+			sb.WriteString(" IN (")
+			sb.WriteString(strings.Join(quoted, ", "))
+			sb.WriteString(")")
+		}
+		sb.WriteString(" THEN ")
+		if err := writeSQLNode(sb, child, attributeTypes, opts, depth+1); err != nil {
+			return fmt.Errorf("branch %s of %s: %w", child.FilterValue, node.Label, err)
+		}
+	}
+	newline(sb, opts.Indent, depth+1)
+	sb.WriteString("ELSE NULL")
+	newline(sb, opts.Indent, depth)
+	sb.WriteString("END")
+
+	return nil
+}
+
+func newline(sb *strings.Builder, indent string, depth int) {
+	if indent == "" {
+		sb.WriteString(" ")
+		return
+	}
+	sb.WriteString("\n")
+	sb.WriteString(strings.Repeat(indent, depth))
+}
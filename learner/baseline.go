@@ -0,0 +1,121 @@
+package learner
+
+import (
+	"context"
+	"github.com/PaluMacil/decisive-oak/parse"
+)
+
+// ZeroR learns to predict the most common target whatever the values, the accuracy
+// any other classifier must beat to have learned anything.
+type ZeroR struct{}
+
+// ZeroRModel predicts the most common training target.
+type ZeroRModel struct {
+	Targets parse.Targets
+	// Counts is the total weight of the training examples of each target
+	Counts []float64
+}
+
+// Fit counts the sample's targets.
+func (ZeroR) Fit(_ context.Context, sample parse.Sample) (Classifier, error) {
+	if err := checkTarget(sample); err != nil {
+		return nil, err
+	}
+	counts, err := targetCounts(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZeroRModel{Targets: sample.Targets, Counts: counts}, nil
+}
+
+// Predict returns the most common training target, preferring the target declared
+// first on ties.
+func (m *ZeroRModel) Predict(map[string]string) (string, error) {
+	return most(m.Targets, m.Counts), nil
+}
+
+// Probabilities returns each target's share of the training examples.
+func (m *ZeroRModel) Probabilities(map[string]string) (map[string]float64, error) {
+	return distribution(m.Targets, m.Counts), nil
+}
+
+// OneR learns a rule on the single nominal attribute whose values best predict the
+// target (Holte, 1993). Real attributes are not considered; discretize them first.
+type OneR struct{}
+
+// OneRModel predicts the most common training target among the examples having the
+// same value of one attribute.
+type OneRModel struct {
+	Targets parse.Targets
+	// Attribute is the attribute whose value decides the prediction
+	Attribute string
+	// Counts is the total weight of the training examples of each target for each
+	// value of the attribute
+	Counts map[string][]float64
+	// Default is the total weight of the training examples of each target, which
+	// decides the prediction for values that no training example had
+	Default []float64
+}
+
+// Fit chooses the attribute whose rule misclassifies the least weight of the
+// sample's examples, preferring the attribute declared first on ties.
+func (OneR) Fit(_ context.Context, sample parse.Sample) (Classifier, error) {
+	if err := checkTarget(sample); err != nil {
+		return nil, err
+	}
+	counts, err := targetCounts(sample)
+	if err != nil {
+		return nil, err
+	}
+	egs, err := newExamples(sample)
+	if err != nil {
+		return nil, err
+	}
+	// with no nominal attribute, the rule falls back to the most common target
+	best := &OneRModel{Targets: sample.Targets, Default: counts}
+	var bestErrors float64
+	for _, at := range sample.AttributeTypes {
+		if at.Real {
+			continue
+		}
+		valueCounts := egs.countValues(at.Name, len(sample.Targets))
+		var errors float64
+		// values are visited in declared order so that the sum does not depend on map order
+		for _, v := range at.Values {
+			var total, highest float64
+			for _, count := range valueCounts[v] {
+				total += count
+				if count > highest {
+					highest = count
+				}
+			}
+			errors += total - highest
+		}
+		if best.Attribute == "" || errors < bestErrors {
+			best.Attribute, best.Counts, bestErrors = at.Name, valueCounts, errors
+		}
+	}
+
+	return best, nil
+}
+
+// Predict returns the most common training target among the examples having the
+// attribute's value, or among every example when none had it or it is missing.
+func (m *OneRModel) Predict(values map[string]string) (string, error) {
+	return most(m.Targets, m.counts(values)), nil
+}
+
+// Probabilities returns each target's share of the training examples having the
+// attribute's value, or of every example when none had it or it is missing.
+func (m *OneRModel) Probabilities(values map[string]string) (map[string]float64, error) {
+	return distribution(m.Targets, m.counts(values)), nil
+}
+
+func (m *OneRModel) counts(values map[string]string) []float64 {
+	if counts, ok := m.Counts[values[m.Attribute]]; ok {
+		return counts
+	}
+
+	return m.Default
+}
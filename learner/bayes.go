@@ -0,0 +1,135 @@
+package learner
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+)
+
+// NaiveBayes learns the probability of each target and of each nominal attribute's
+// values given the target, and predicts by Bayes' rule as though the attributes were
+// independent given the target. Real attributes are not considered; discretize
+// them first.
+type NaiveBayes struct {
+	// Alpha is added to every count so that a value never seen with a target does
+	// not rule the target out, 1 (Laplace smoothing) when zero.
+	Alpha float64
+}
+
+// NaiveBayesModel holds the counts a NaiveBayes learner took from its sample.
+type NaiveBayesModel struct {
+	Targets parse.Targets
+	Alpha   float64
+	// Counts is the total weight of the training examples of each target
+	Counts     []float64
+	Attributes []NaiveBayesAttribute
+}
+
+// NaiveBayesAttribute holds the total weight of the training examples of each
+// target for each value of a nominal attribute.
+type NaiveBayesAttribute struct {
+	Name   string
+	Values []string
+	Counts map[string][]float64
+}
+
+// Fit counts the sample's targets and, for each target, the values of each nominal
+// attribute.
+func (l NaiveBayes) Fit(_ context.Context, sample parse.Sample) (Classifier, error) {
+	if err := checkTarget(sample); err != nil {
+		return nil, err
+	}
+	if l.Alpha < 0 {
+		return nil, fmt.Errorf("smoothing %v is negative", l.Alpha)
+	}
+	if l.Alpha == 0 {
+		l.Alpha = 1
+	}
+	counts, err := targetCounts(sample)
+	if err != nil {
+		return nil, err
+	}
+	egs, err := newExamples(sample)
+	if err != nil {
+		return nil, err
+	}
+	m := &NaiveBayesModel{Targets: sample.Targets, Alpha: l.Alpha, Counts: counts}
+	for _, at := range sample.AttributeTypes {
+		if at.Real {
+			continue
+		}
+		m.Attributes = append(m.Attributes, NaiveBayesAttribute{
+			Name:   at.Name,
+			Values: at.Values,
+			Counts: egs.countValues(at.Name, len(sample.Targets)),
+		})
+	}
+
+	return m, nil
+}
+
+// Predict returns the most probable target, preferring the target declared first
+// on ties.
+func (m *NaiveBayesModel) Predict(values map[string]string) (string, error) {
+	logs := m.logProbabilities(values)
+	best := 0
+	for t, l := range logs {
+		if l > logs[best] {
+			best = t
+		}
+	}
+
+	return m.Targets[best], nil
+}
+
+// Probabilities returns the probability of each target given the values. Missing
+// values, and values that are not declared for their attribute, are left out.
+func (m *NaiveBayesModel) Probabilities(values map[string]string) (map[string]float64, error) {
+	logs := m.logProbabilities(values)
+	// the largest log probability is subtracted so that the exponents do not underflow
+	highest := math.Inf(-1)
+	for _, l := range logs {
+		highest = math.Max(highest, l)
+	}
+	scaled := make([]float64, len(logs))
+	for t, l := range logs {
+		scaled[t] = math.Exp(l - highest)
+	}
+
+	return distribution(m.Targets, scaled), nil
+}
+
+// logProbabilities returns the logarithm of the smoothed probability of each target
+// times that of each given value given the target, up to a constant.
+func (m *NaiveBayesModel) logProbabilities(values map[string]string) []float64 {
+	logs := make([]float64, len(m.Targets))
+	for t, count := range m.Counts {
+		logs[t] = math.Log(count + m.Alpha)
+	}
+	for _, at := range m.Attributes {
+		v, ok := values[at.Name]
+		if !ok || !declared(at.Values, v) {
+			continue
+		}
+		for t, count := range m.Counts {
+			var valueCount float64
+			if c := at.Counts[v]; c != nil {
+				valueCount = c[t]
+			}
+			logs[t] += math.Log((valueCount + m.Alpha) / (count + m.Alpha*float64(len(at.Values))))
+		}
+	}
+
+	return logs
+}
+
+func declared(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,183 @@
+// Package learner puts the ID3 tree and simple baselines behind common interfaces,
+// so that they can be trained and compared on the same data.
+package learner
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+)
+
+// Learner trains a Classifier from a sample with a nominal target.
+type Learner interface {
+	Fit(ctx context.Context, sample parse.Sample) (Classifier, error)
+}
+
+// Classifier predicts the target of examples given their values keyed by
+// attribute name.
+type Classifier interface {
+	// Predict returns the most likely target.
+	Predict(values map[string]string) (string, error)
+	// Probabilities returns the estimated probability of each target, which sum to one.
+	Probabilities(values map[string]string) (map[string]float64, error)
+}
+
+// Tree learns an ID3 tree built with Options, which classifies values not seen in
+// training by the Unseen strategy.
+type Tree struct {
+	Options analysis.Options
+	Unseen  analysis.UnseenStrategy
+}
+
+// TreeModel is a Classifier for a built tree.
+type TreeModel struct {
+	Classifier analysis.Classifier
+}
+
+// Fit builds the tree.
+func (l Tree) Fit(ctx context.Context, sample parse.Sample) (Classifier, error) {
+	if err := checkTarget(sample); err != nil {
+		return nil, err
+	}
+	tree, err := analysis.BuildTreeContext(ctx, sample, l.Options)
+	if err != nil {
+		return nil, fmt.Errorf("fitting tree: %w", err)
+	}
+
+	return &TreeModel{Classifier: analysis.Classifier{Tree: tree, Unseen: l.Unseen, Costs: l.Options.Costs}}, nil
+}
+
+// Predict returns the label the tree predicts.
+func (m *TreeModel) Predict(values map[string]string) (string, error) {
+	return m.Classifier.Classify(values)
+}
+
+// Probabilities returns the share of each target among the training examples of
+// the leaves reached, or certainty in the label of a leaf no example reached.
+func (m *TreeModel) Probabilities(values map[string]string) (map[string]float64, error) {
+	p, err := m.Classifier.Predict(values)
+	if err != nil {
+		return nil, err
+	}
+	var total float64
+	for _, count := range p.TargetCounts {
+		total += count
+	}
+	if total == 0 {
+		return map[string]float64{p.Label: 1}, nil
+	}
+	probabilities := make(map[string]float64, len(p.TargetCounts))
+	for t, count := range p.TargetCounts {
+		probabilities[t] = count / total
+	}
+
+	return probabilities, nil
+}
+
+// ClassifyExample predicts the target of a parsed example whose values are laid
+// out according to the given attribute types.
+func ClassifyExample(c Classifier, attributeTypes parse.AttributeTypes, eg parse.Example) (string, error) {
+	values, err := attributeTypes.ExampleValues(eg)
+	if err != nil {
+		return "", fmt.Errorf("classifying example: %w", err)
+	}
+
+	return c.Predict(values)
+}
+
+func checkTarget(sample parse.Sample) error {
+	if sample.RealTarget != "" {
+		return fmt.Errorf("classifiers need a nominal target, not the real target %s", sample.RealTarget)
+	}
+	if len(sample.Examples) == 0 {
+		return fmt.Errorf("the sample has no examples to learn from")
+	}
+
+	return nil
+}
+
+// targetCounts returns the total weight of the sample's examples of each target,
+// in declared order.
+func targetCounts(sample parse.Sample) ([]float64, error) {
+	counts := make([]float64, len(sample.Targets))
+	for i, eg := range sample.Examples {
+		t, err := sample.Targets.Index(eg.Target)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		counts[t] += eg.EffectiveWeight()
+	}
+
+	return counts, nil
+}
+
+// examples holds the values, target codes and weights of a sample's examples.
+type examples struct {
+	values  []map[string]string
+	targets []int
+	weights []float64
+}
+
+func newExamples(sample parse.Sample) (*examples, error) {
+	e := &examples{
+		values:  make([]map[string]string, len(sample.Examples)),
+		targets: make([]int, len(sample.Examples)),
+		weights: make([]float64, len(sample.Examples)),
+	}
+	for i, eg := range sample.Examples {
+		values, err := sample.AttributeTypes.ExampleValues(eg)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		t, err := sample.Targets.Index(eg.Target)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		e.values[i], e.targets[i], e.weights[i] = values, t, eg.EffectiveWeight()
+	}
+
+	return e, nil
+}
+
+// countValues returns the total weight of the examples of each of the given number
+// of targets for each value of the attribute.
+func (e *examples) countValues(attribute string, numTargets int) map[string][]float64 {
+	valueCounts := make(map[string][]float64)
+	for i, values := range e.values {
+		v := values[attribute]
+		if valueCounts[v] == nil {
+			valueCounts[v] = make([]float64, numTargets)
+		}
+		valueCounts[v][e.targets[i]] += e.weights[i]
+	}
+
+	return valueCounts
+}
+
+// most returns the target with the greatest count, preferring the target declared
+// first on ties.
+func most(targets parse.Targets, counts []float64) string {
+	best := 0
+	for t, count := range counts {
+		if count > counts[best] {
+			best = t
+		}
+	}
+
+	return targets[best]
+}
+
+// distribution returns each target's share of the total count.
+func distribution(targets parse.Targets, counts []float64) map[string]float64 {
+	var total float64
+	for _, count := range counts {
+		total += count
+	}
+	probabilities := make(map[string]float64, len(targets))
+	for t, count := range counts {
+		probabilities[targets[t]] = count / total
+	}
+
+	return probabilities
+}
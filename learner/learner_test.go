@@ -0,0 +1,130 @@
+package learner
+
+import (
+	"context"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func lenses(t *testing.T) parse.Sample {
+	t.Helper()
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+
+	return sample
+}
+
+func accuracy(t *testing.T, c Classifier, sample parse.Sample) float64 {
+	t.Helper()
+	var correct int
+	for _, eg := range sample.Examples {
+		label, err := ClassifyExample(c, sample.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example: %v", err)
+		}
+		if label == eg.Target {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(len(sample.Examples))
+}
+
+func TestLearners(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample := lenses(t)
+	values := map[string]string{"age": "young", "prescription": "myope", "astigmatism": "yes", "tear-rate": "normal"}
+	// 15 of the 24 examples are none, as are all 12 with reduced tears
+	tests := []struct {
+		name     string
+		learner  Learner
+		accuracy float64
+		label    string
+	}{
+		{"tree", Tree{}, 1, "hard"},
+		{"zero r", ZeroR{}, 15.0 / 24, "none"},
+		{"one r", OneR{}, 17.0 / 24, "soft"},
+		{"naive bayes", NaiveBayes{}, 0, "hard"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := test.learner.Fit(context.Background(), sample)
+			if err != nil {
+				t.Fatalf("fitting: %v", err)
+			}
+			got := accuracy(t, c, sample)
+			if test.accuracy > 0 && got != test.accuracy {
+				t.Errorf("expected training accuracy %v, got %v", test.accuracy, got)
+			}
+			if got < 15.0/24 {
+				t.Errorf("expected at least the accuracy of ZeroR, got %v", got)
+			}
+			label, err := c.Predict(values)
+			if err != nil || label != test.label {
+				t.Errorf("expected %s, got %s (%v)", test.label, label, err)
+			}
+			probabilities, err := c.Probabilities(values)
+			if err != nil {
+				t.Fatalf("estimating probabilities: %v", err)
+			}
+			var sum float64
+			for target, p := range probabilities {
+				sum += p
+				if p > probabilities[label] {
+					t.Errorf("expected %s to be the most probable, but %s has %v", label, target, p)
+				}
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("expected probabilities to sum to one, got %v", sum)
+			}
+		})
+	}
+}
+
+func TestOneR(t *testing.T) {
+	c, err := OneR{}.Fit(context.Background(), lenses(t))
+	if err != nil {
+		t.Fatalf("fitting: %v", err)
+	}
+	m := c.(*OneRModel)
+	if m.Attribute != "tear-rate" {
+		t.Errorf("expected the rule to use tear-rate, got %s", m.Attribute)
+	}
+	// a value no training example had falls back to the most common target
+	if label, _ := m.Predict(map[string]string{"tear-rate": "watery"}); label != "none" {
+		t.Errorf("expected none for an unseen value, got %s", label)
+	}
+}
+
+func TestNaiveBayes(t *testing.T) {
+	sample := lenses(t)
+	c, err := NaiveBayes{}.Fit(context.Background(), sample)
+	if err != nil {
+		t.Fatalf("fitting: %v", err)
+	}
+	// with no values, the probabilities are the smoothed priors (5+1, 4+1, 15+1) / 27
+	probabilities, err := c.Probabilities(map[string]string{})
+	if err != nil {
+		t.Fatalf("estimating probabilities: %v", err)
+	}
+	for target, want := range map[string]float64{"soft": 6.0 / 27, "hard": 5.0 / 27, "none": 16.0 / 27} {
+		if math.Abs(probabilities[target]-want) > 1e-9 {
+			t.Errorf("expected probability %v for %s, got %v", want, target, probabilities[target])
+		}
+	}
+	if _, err = (NaiveBayes{Alpha: -1}).Fit(context.Background(), sample); err == nil {
+		t.Error("expected an error for negative smoothing")
+	}
+	real := sample
+	real.RealTarget, real.Targets = "lenses", nil
+	if _, err = (NaiveBayes{}).Fit(context.Background(), real); err == nil {
+		t.Error("expected an error for a real target")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/PaluMacil/decisive-oak/analysis"
 	"github.com/PaluMacil/decisive-oak/parse"
+	"github.com/PaluMacil/decisive-oak/pmml"
+	"github.com/PaluMacil/decisive-oak/profile"
 	"io/ioutil"
 	"os"
 	"path"
@@ -13,6 +15,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
 	files, err := filepath.Glob("data/*.data.txt")
 	if err != nil {
 		fmt.Printf("finding data files: %v", err)
@@ -38,6 +44,24 @@ func main() {
 			os.Exit(1)
 		}
 
+		p, err := profile.New(sample, profile.Options{})
+		if err != nil {
+			fmt.Printf("profiling %s: %v", filename, err)
+			os.Exit(1)
+		}
+		jsonData, err = json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			fmt.Printf("marshalling profile to JSON: %v", err)
+			os.Exit(1)
+		}
+		profileFilename := strings.TrimSuffix(filename, path.Ext(filename)) + ".profile.json"
+		profileFilename = path.Join("out", filepath.Base(profileFilename))
+		err = ioutil.WriteFile(profileFilename, jsonData, 0644)
+		if err != nil {
+			fmt.Printf("writing profile file: %v", err)
+			os.Exit(1)
+		}
+
 		rootNode, err := analysis.BuildTree(sample)
 		if err != nil {
 			fmt.Printf("building tree failed: %s", err.Error())
@@ -55,6 +79,25 @@ func main() {
 			fmt.Printf("writing tree file: %v", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Wrote %s\n\n", treeFilename)
+		fmt.Printf("Wrote %s\n", treeFilename)
+
+		pmmlFilename := strings.TrimSuffix(treeFilename, ".tree.json") + ".pmml"
+		pmmlFile, err := os.Create(pmmlFilename)
+		if err != nil {
+			fmt.Printf("creating PMML file: %v", err)
+			os.Exit(1)
+		}
+		err = pmml.Encode(pmmlFile, pmml.Model{
+			Name:           filepath.Base(filename),
+			Targets:        sample.Targets,
+			AttributeTypes: sample.AttributeTypes,
+			Tree:           rootNode,
+		})
+		pmmlFile.Close()
+		if err != nil {
+			fmt.Printf("writing PMML file: %v", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n\n", pmmlFilename)
 	}
 }
@@ -0,0 +1,72 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a problem at a specific place in a data file.
+type ParseError struct {
+	// File is the name of the file being parsed, if known
+	File string
+	// Line is the 1-based line number in the file
+	Line int
+	// Field is the 1-based comma-separated field in the line, or 0 when the whole line is at fault
+	Field int
+	// Value is the offending text
+	Value string
+	// Expected lists the values that would have been accepted, if there is a fixed set
+	Expected []string
+	Msg      string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.File != "" {
+		sb.WriteString(e.File)
+		sb.WriteString(":")
+	}
+	fmt.Fprintf(&sb, "%d", e.Line)
+	if e.Field > 0 {
+		fmt.Fprintf(&sb, ":%d", e.Field)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(e.Msg)
+	if e.Value != "" {
+		fmt.Fprintf(&sb, " %q", e.Value)
+	}
+	if len(e.Expected) > 0 {
+		fmt.Fprintf(&sb, ", expected one of %v", e.Expected)
+	}
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors is every error found in a file when parsing leniently.
+type ParseErrors []*ParseError
+
+func (pe ParseErrors) Error() string {
+	switch len(pe) {
+	case 0:
+		return "no parse errors"
+	case 1:
+		return pe[0].Error()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d parse errors:", len(pe))
+	for _, e := range pe {
+		sb.WriteString("\n\t")
+		sb.WriteString(e.Error())
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+package parse_test
+
+import (
+	"errors"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"strings"
+	"testing"
+)
+
+const badExamples = `2
+Yes,No
+2
+Wind,2,Strong,Weak
+Water,3,Warm,Moderate,Cold
+4
+Strong,Warm,Yes
+
+Weak,Hot,No
+Strong,Cold,Maybe
+Weak,Cold,No,Extra
+`
+
+func TestParseWith_ErrorLocation(t *testing.T) {
+	_, err := parse.ParseWith(strings.NewReader(badExamples), parse.ParseOptions{File: "fishing.txt"})
+	var parseErr *parse.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	// line 8 is empty, so the first bad example is on line 9
+	if parseErr.Line != 9 || parseErr.Field != 2 || parseErr.Value != "Hot" {
+		t.Errorf("expected line 9 field 2 value Hot, got line %d field %d value %s",
+			parseErr.Line, parseErr.Field, parseErr.Value)
+	}
+	if len(parseErr.Expected) != 3 || parseErr.Expected[0] != "Warm" {
+		t.Errorf("expected the values of Water, got %v", parseErr.Expected)
+	}
+	expected := `fishing.txt:9:2: invalid value for attribute Water "Hot", expected one of [Warm Moderate Cold]`
+	if err.Error() != expected {
+		t.Errorf("expected message %s, got %s", expected, err.Error())
+	}
+}
+
+func TestParseWith_Lenient(t *testing.T) {
+	sample, err := parse.ParseWith(strings.NewReader(badExamples), parse.ParseOptions{Lenient: true})
+	var parseErrs parse.ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("expected ParseErrors, got %v", err)
+	}
+	if len(parseErrs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %s", len(parseErrs), err.Error())
+	}
+	lines := []int{9, 10, 11}
+	fields := []int{2, 3, 0}
+	for i, e := range parseErrs {
+		if e.Line != lines[i] || e.Field != fields[i] {
+			t.Errorf("error %d: expected line %d field %d, got line %d field %d",
+				i, lines[i], fields[i], e.Line, e.Field)
+		}
+	}
+	if len(sample.Examples) != 1 || sample.NumExamples != 1 {
+		t.Errorf("expected the one valid example, got %d with NumExamples %d",
+			len(sample.Examples), sample.NumExamples)
+	}
+}
+
+func TestParseWith_HeaderError(t *testing.T) {
+	header := strings.Replace(badExamples, "Water,3", "Water,three", 1)
+	_, err := parse.ParseWith(strings.NewReader(header), parse.ParseOptions{Lenient: true})
+	var parseErrs parse.ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs) != 1 {
+		t.Fatalf("expected a single header error, got %v", err)
+	}
+	if parseErrs[0].Line != 5 || parseErrs[0].Field != 2 || parseErrs[0].Value != "three" {
+		t.Errorf("expected line 5 field 2 value three, got %s", parseErrs[0].Error())
+	}
+}
+
+func TestFromFileWith_Filename(t *testing.T) {
+	sample, err := parse.FromFileWith("../data/fishing.data.txt", parse.ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("expected the bundled data to parse cleanly, got %v", err)
+	}
+	if sample.NumExamples != 14 {
+		t.Errorf("expected 14 examples, got %d", sample.NumExamples)
+	}
+}
@@ -0,0 +1,370 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Reader parses the header of a data file up front and then its examples one at
+// a time, so that files of any size can be processed without holding every line
+// in memory.
+type Reader struct {
+	scanner    *bufio.Scanner
+	lineNumber int
+	header     Sample
+	// read is the number of example lines consumed, valid or not
+	read int
+	// projection is set when the data is described by a Schema rather than a header
+	projection *projection
+	p          parser
+}
+
+// line is a non-empty line of a data file and its 1-based line number
+type line struct {
+	number int
+	text   string
+}
+
+// NewReader reads and validates the targets, attribute declarations and example
+// count from the start of the data.
+func NewReader(reader io.Reader, opts ParseOptions) (*Reader, error) {
+	r := &Reader{scanner: bufio.NewScanner(reader), p: parser{opts: opts}}
+	numTargetsLine, err := r.headerLine("number of targets")
+	if err != nil {
+		return nil, err
+	}
+	r.header.NumTargets, err = r.parseCount(numTargetsLine, "targets")
+	if err != nil {
+		return nil, err
+	}
+	targetsLine, err := r.headerLine("targets")
+	if err != nil {
+		return nil, err
+	}
+	r.header.Targets = strings.Split(targetsLine.text, ",")
+	if r.header.NumTargets == 1 && len(r.header.Targets) == 2 && r.header.Targets[1] == "real" {
+		// a single real-valued target is declared like a real attribute
+		r.header.RealTarget, r.header.Targets = r.header.Targets[0], nil
+	} else if len(r.header.Targets) != r.header.NumTargets {
+		return nil, r.p.fail(targetsLine, 0, "", fmt.Sprintf("expected %d targets, found %d",
+			r.header.NumTargets, len(r.header.Targets)), nil)
+	}
+	numAttributesLine, err := r.headerLine("number of attributes")
+	if err != nil {
+		return nil, err
+	}
+	r.header.NumAttributes, err = r.parseCount(numAttributesLine, "attributes")
+	if err != nil {
+		return nil, err
+	}
+	// the count is not trusted to size the slice, since the file may end well before it
+	var attributeLines []line
+	for i := 0; i < r.header.NumAttributes; i++ {
+		l, err := r.headerLine("attributes and example count")
+		if err != nil {
+			return nil, err
+		}
+		attributeLines = append(attributeLines, l)
+	}
+	r.header.AttributeTypes, r.header.Weight, err = r.p.parseAttributeTypes(attributeLines)
+	if err != nil {
+		return nil, err
+	}
+	// the weight declaration is not an attribute
+	r.header.NumAttributes = len(r.header.AttributeTypes)
+	numExamplesLine, err := r.headerLine("attributes and example count")
+	if err != nil {
+		return nil, err
+	}
+	r.header.NumExamples, err = r.parseCount(numExamplesLine, "examples")
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// parseCount parses a header line holding the number of something, which must not
+// be negative.
+func (r *Reader) parseCount(l line, what string) (int, error) {
+	count, err := strconv.Atoi(l.text)
+	if err == nil && count < 0 {
+		err = fmt.Errorf("counts cannot be negative")
+	}
+	if err != nil {
+		return 0, r.p.fail(l, 1, l.text, "parsing number of "+what, err)
+	}
+
+	return count, nil
+}
+
+// Header returns the sample described by the header, without any examples.
+func (r *Reader) Header() Sample {
+	return r.header
+}
+
+// Next returns the next valid example, or io.EOF once the number of examples
+// declared in the header have been read, or at the end of data described by a
+// Schema. When lenient, invalid examples are skipped and their errors are
+// available from Errors.
+func (r *Reader) Next() (Example, error) {
+	for r.projection != nil || r.read < r.header.NumExamples {
+		l, err := r.nextLine()
+		if err == io.EOF && r.projection != nil {
+			return Example{}, io.EOF
+		}
+		if err == io.EOF {
+			return Example{}, r.p.fail(line{number: r.lineNumber}, 0, "",
+				fmt.Sprintf("not enough lines left for %d examples", r.header.NumExamples), nil)
+		}
+		if err != nil {
+			return Example{}, err
+		}
+		r.read++
+		eg, errs := r.parseExample(l)
+		if len(errs) == 0 {
+			return eg, nil
+		}
+		for _, e := range errs {
+			e.File = r.p.opts.File
+		}
+		if !r.p.opts.Lenient {
+			return Example{}, errs[0]
+		}
+		r.p.errs = append(r.p.errs, errs...)
+	}
+
+	return Example{}, io.EOF
+}
+
+// Errors returns the errors collected while reading leniently.
+func (r *Reader) Errors() ParseErrors {
+	return r.p.errs
+}
+
+// parseExample parses a line of data, mapping it through the schema's columns
+// when the data is described by a Schema.
+func (r *Reader) parseExample(l line) (Example, []*ParseError) {
+	if r.projection == nil {
+		return parseExample(r.header, l)
+	}
+	projected, columns, err := r.projection.project(l)
+	if err != nil {
+		return Example{}, []*ParseError{err}
+	}
+	eg, errs := parseExample(r.header, projected)
+	for _, e := range errs {
+		if e.Field > 0 {
+			e.Field = columns[e.Field-1]
+		}
+	}
+
+	return eg, errs
+}
+
+// nextLine returns the next non-empty line.
+func (r *Reader) nextLine() (line, error) {
+	for r.scanner.Scan() {
+		r.lineNumber++
+		text := r.scanner.Text()
+		// skip empty lines
+		if text == "" {
+			continue
+		}
+		return line{number: r.lineNumber, text: text}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return line{}, fmt.Errorf("reading line %d: %w", r.lineNumber+1, err)
+	}
+
+	return line{}, io.EOF
+}
+
+func (r *Reader) headerLine(reading string) (line, error) {
+	l, err := r.nextLine()
+	if err == io.EOF {
+		return l, r.p.fail(line{number: r.lineNumber}, 0, "", "not enough lines left for "+reading, nil)
+	}
+
+	return l, err
+}
+
+// parser carries the options and, when lenient, the errors collected so far
+type parser struct {
+	opts ParseOptions
+	errs ParseErrors
+}
+
+// fail returns an error for a problem that prevents parsing from continuing,
+// including any errors collected before it.
+func (p *parser) fail(l line, field int, value, msg string, err error) error {
+	parseErr := &ParseError{
+		File:  p.opts.File,
+		Line:  l.number,
+		Field: field,
+		Value: value,
+		Msg:   msg,
+		Err:   err,
+	}
+	if p.opts.Lenient {
+		p.errs = append(p.errs, parseErr)
+		return p.errs
+	}
+
+	return parseErr
+}
+
+// parseAttributeTypes parses the attribute declarations, any one of which may
+// instead declare the weight column as "<name>,weight". Declarations beginning
+// "<name>,ordinal," list their values in order.
+func (p *parser) parseAttributeTypes(lines []line) (AttributeTypes, *WeightColumn, error) {
+	types := make([]AttributeType, 0, len(lines))
+	var weight *WeightColumn
+	for i, l := range lines {
+		splits := strings.Split(l.text, ",")
+		if len(splits) < 2 {
+			return types, weight, p.fail(l, 0, "", fmt.Sprintf("not enough data in line %d of attribute lines", i), nil)
+		}
+		if splits[1] == "weight" {
+			if weight != nil {
+				return types, weight, p.fail(l, 2, splits[1],
+					fmt.Sprintf("weight column %s is already declared", weight.Name), nil)
+			}
+			weight = &WeightColumn{Name: splits[0], Field: i}
+			continue
+		}
+		at := AttributeType{Name: splits[0]}
+		if splits[1] == "real" {
+			at.Real = true
+			types = append(types, at)
+			continue
+		}
+		field := 2
+		if splits[1] == "ordinal" {
+			at.Ordinal = true
+			splits = append(splits[:1], splits[2:]...)
+			field++
+			if len(splits) < 2 {
+				return types, weight, p.fail(l, 0, "", fmt.Sprintf("not enough data in line %d of attribute lines", i), nil)
+			}
+		}
+		numValues, err := strconv.Atoi(splits[1])
+		if err != nil {
+			return types, weight, p.fail(l, field, splits[1], fmt.Sprintf("parsing number of attribute values for %s", splits[0]), err)
+		}
+		at.NumValues = numValues
+		at.Values = splits[2:]
+		foundValues := len(at.Values)
+		if foundValues != numValues {
+			return types, weight, p.fail(l, 0, "", fmt.Sprintf("incorrect number of values for %s: expected %d but found %d",
+				splits[0], numValues, foundValues), nil)
+		}
+		types = append(types, at)
+	}
+
+	return types, weight, nil
+}
+
+// parseExample parses the fields of an example line laid out as the header declares.
+func parseExample(header Sample, l line) (Example, []*ParseError) {
+	eg := Example{Line: l.number}
+	var errs []*ParseError
+	attributeTypes := header.AttributeTypes
+	numFields := len(attributeTypes) + 1
+	weightField := -1
+	if header.Weight != nil {
+		numFields++
+		weightField = header.Weight.Field
+	}
+	splits := strings.Split(l.text, ",")
+	if len(splits) != numFields {
+		return eg, []*ParseError{{
+			Line: l.number,
+			Msg: fmt.Sprintf("expected %d attributes and one target in splits, got %d total",
+				numFields-1, len(splits)),
+		}}
+	}
+	lastSplitIndex := len(splits) - 1
+	target := splits[lastSplitIndex]
+	var idxAttribute int
+	for idxField, v := range splits[:lastSplitIndex] {
+		if idxField == weightField {
+			weight, err := strconv.ParseFloat(v, 64)
+			if err == nil && (!(weight > 0) || math.IsInf(weight, 1)) {
+				err = fmt.Errorf("weights must be positive")
+			}
+			if err != nil {
+				errs = append(errs, &ParseError{
+					Line:  l.number,
+					Field: idxField + 1,
+					Value: v,
+					Msg:   fmt.Sprintf("invalid weight %s", header.Weight.Name),
+					Err:   err,
+				})
+			}
+			eg.Weight = weight
+			continue
+		}
+		at := attributeTypes[idxAttribute]
+		idxAttribute++
+		if at.Real {
+			realValue, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, &ParseError{
+					Line:  l.number,
+					Field: idxField + 1,
+					Value: v,
+					Msg:   fmt.Sprintf("invalid real value for attribute %s", at.Name),
+					Err:   err,
+				})
+				continue
+			}
+			eg.RealValues = append(eg.RealValues, realValue)
+			continue
+		}
+		if !at.IsValidValue(v) {
+			errs = append(errs, &ParseError{
+				Line:     l.number,
+				Field:    idxField + 1,
+				Value:    v,
+				Expected: at.Values,
+				Msg:      fmt.Sprintf("invalid value for attribute %s", at.Name),
+			})
+			continue
+		}
+		eg.StringValues = append(eg.StringValues, v)
+	}
+	if header.RealTarget != "" {
+		value, err := strconv.ParseFloat(target, 64)
+		if err == nil && (math.IsNaN(value) || math.IsInf(value, 0)) {
+			err = fmt.Errorf("target values must be finite")
+		}
+		if err != nil {
+			errs = append(errs, &ParseError{
+				Line:  l.number,
+				Field: lastSplitIndex + 1,
+				Value: target,
+				Msg:   fmt.Sprintf("invalid real target %s", header.RealTarget),
+				Err:   err,
+			})
+		}
+		eg.Value = value
+		return eg, errs
+	}
+	if !header.Targets.IsValid(target) {
+		errs = append(errs, &ParseError{
+			Line:     l.number,
+			Field:    lastSplitIndex + 1,
+			Value:    target,
+			Expected: header.Targets,
+			Msg:      "invalid target",
+		})
+	}
+	eg.Target = target
+
+	return eg, errs
+}
@@ -0,0 +1,118 @@
+package parse_test
+
+import (
+	"errors"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReader_Next(t *testing.T) {
+	file, err := os.Open("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("opening contact-lenses.data.txt: %v", err)
+	}
+	defer file.Close()
+	r, err := parse.NewReader(file, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("reading header: %s", err.Error())
+	}
+	header := r.Header()
+	if !reflect.DeepEqual(header.AttributeTypes, attributeTypes) {
+		t.Errorf("expected attribute types %v, got %v", attributeTypes, header.AttributeTypes)
+	}
+	if header.NumExamples != 24 || header.Examples != nil {
+		t.Errorf("expected a header for 24 examples without examples, got %d and %v",
+			header.NumExamples, header.Examples)
+	}
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	var count int
+	for {
+		eg, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading example %d: %s", count, err.Error())
+		}
+		if !reflect.DeepEqual(eg, sample.Examples[count]) {
+			t.Errorf("example %d: expected %v, got %v", count, sample.Examples[count], eg)
+		}
+		count++
+	}
+	if count != 24 {
+		t.Errorf("expected 24 examples, got %d", count)
+	}
+}
+
+func TestReader_Lenient(t *testing.T) {
+	r, err := parse.NewReader(strings.NewReader(badExamples), parse.ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("reading header: %s", err.Error())
+	}
+	eg, err := r.Next()
+	if err != nil || eg.Target != "Yes" {
+		t.Fatalf("expected the first example, got %v, %v", eg, err)
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Errorf("expected the invalid examples to be skipped, got %v", err)
+	}
+	if len(r.Errors()) != 3 {
+		t.Errorf("expected 3 collected errors, got %d", len(r.Errors()))
+	}
+}
+
+func TestReader_Truncated(t *testing.T) {
+	data, err := ioutil.ReadFile("../data/new-treatment.data.txt")
+	if err != nil {
+		t.Fatalf("reading new-treatment.data.txt: %v", err)
+	}
+	// declare one more example than the file has
+	truncated := strings.Replace(string(data), "\n5\n", "\n6\n", 1)
+	r, err := parse.NewReader(strings.NewReader(truncated), parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("reading header: %s", err.Error())
+	}
+	for i := 0; i < 5; i++ {
+		if _, err = r.Next(); err != nil {
+			t.Fatalf("reading example %d: %s", i, err.Error())
+		}
+	}
+	_, err = r.Next()
+	var parseErr *parse.ParseError
+	if !errors.As(err, &parseErr) || !strings.Contains(parseErr.Msg, "not enough lines left for 6 examples") {
+		t.Errorf("expected an error for the missing example, got %v", err)
+	}
+
+	_, err = parse.NewReader(strings.NewReader("2\nYes,No\n"), parse.ParseOptions{})
+	if !errors.As(err, &parseErr) || !strings.Contains(parseErr.Msg, "number of attributes") {
+		t.Errorf("expected an error for the missing attribute count, got %v", err)
+	}
+}
+
+func TestNewReader_BadCounts(t *testing.T) {
+	tests := []struct {
+		data  string
+		line  int
+		value string
+	}{
+		{"-2\nYes,No\n", 1, "-2"},
+		{"2\nYes,No\n-1\n", 3, "-1"},
+		{"2\nYes,No\n99999999999999999999\n", 3, "99999999999999999999"},
+		{"2\nYes,No\n1\nWind,2,Weak,Strong\n-5\nWeak,Yes\n", 5, "-5"},
+	}
+	for _, tt := range tests {
+		_, err := parse.NewReader(strings.NewReader(tt.data), parse.ParseOptions{})
+		var parseErr *parse.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != tt.line || parseErr.Value != tt.value {
+			t.Errorf("expected an error at line %d for count %s, got %v", tt.line, tt.value, err)
+		}
+	}
+}
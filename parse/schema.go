@@ -0,0 +1,355 @@
+package parse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Column types in a Schema
+const (
+	ColumnNominal = "nominal"
+	// ColumnOrdinal columns are nominal columns whose values are listed in order
+	ColumnOrdinal = "ordinal"
+	ColumnReal    = "real"
+	// ColumnIgnore columns are skipped when reading data
+	ColumnIgnore = "ignore"
+	// ColumnID columns identify rows and are skipped when reading data
+	ColumnID = "id"
+	// ColumnWeight holds the weight of each example
+	ColumnWeight = "weight"
+)
+
+// Schema describes the columns of a headerless comma-separated data file so that
+// it can be read without the count-prefixed header of the .data.txt format.
+type Schema struct {
+	// Header is true when the first line of the data names the columns rather than
+	// holding an example. The names must match the schema's columns.
+	Header bool `json:"header,omitempty"`
+	// Target names the nominal column whose values are the targets, or the real
+	// column whose values a regression tree predicts
+	Target  string   `json:"target"`
+	Columns []Column `json:"columns"`
+}
+
+// Column describes one comma-separated field of each line of data.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Values are the accepted values of a nominal or ordinal column, in order for an ordinal one
+	Values []string `json:"values,omitempty"`
+}
+
+// SchemaFromFile reads a JSON schema from the named file.
+func SchemaFromFile(filename string) (Schema, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Schema{}, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	defer file.Close()
+	return ReadSchema(file)
+}
+
+// ReadSchema decodes and validates a JSON schema.
+func ReadSchema(reader io.Reader) (Schema, error) {
+	var s Schema
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return s, fmt.Errorf("decoding schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// Write encodes the schema as indented JSON.
+func (s Schema) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return fmt.Errorf("encoding schema: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks that column names are unique, types are known, nominal and
+// ordinal columns declare their values, there is at most one weight column and the target is a
+// nominal or real column.
+func (s Schema) Validate() error {
+	names := make(map[string]bool, len(s.Columns))
+	var foundTarget, foundWeight bool
+	for i, c := range s.Columns {
+		if c.Name == "" {
+			return fmt.Errorf("column %d has no name", i+1)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("column %s is declared more than once", c.Name)
+		}
+		names[c.Name] = true
+		switch c.Type {
+		case ColumnNominal, ColumnOrdinal:
+			if len(c.Values) == 0 {
+				return fmt.Errorf("%s column %s declares no values", c.Type, c.Name)
+			}
+		case ColumnWeight:
+			if foundWeight {
+				return fmt.Errorf("column %s is a second weight column", c.Name)
+			}
+			foundWeight = true
+		case ColumnReal, ColumnIgnore, ColumnID:
+		default:
+			return fmt.Errorf("column %s has unknown type %q", c.Name, c.Type)
+		}
+		if c.Name == s.Target {
+			if c.Type != ColumnNominal && c.Type != ColumnReal {
+				return fmt.Errorf("target column %s must be nominal or real, not %s", c.Name, c.Type)
+			}
+			foundTarget = true
+		}
+	}
+	if !foundTarget {
+		return fmt.Errorf("target column %q is not declared", s.Target)
+	}
+
+	return nil
+}
+
+// Sample returns the targets and attribute types that data read with the schema
+// has, without any examples.
+func (s Schema) Sample() Sample {
+	var sample Sample
+	for _, c := range s.Columns {
+		switch {
+		case c.Name == s.Target && c.Type == ColumnReal:
+			sample.RealTarget = c.Name
+		case c.Name == s.Target:
+			sample.Targets = c.Values
+		case c.Type == ColumnNominal || c.Type == ColumnOrdinal:
+			sample.AttributeTypes = append(sample.AttributeTypes, AttributeType{
+				Name:      c.Name,
+				NumValues: len(c.Values),
+				Values:    c.Values,
+				Ordinal:   c.Type == ColumnOrdinal,
+			})
+		case c.Type == ColumnReal:
+			sample.AttributeTypes = append(sample.AttributeTypes, AttributeType{Name: c.Name, Real: true})
+		case c.Type == ColumnWeight:
+			sample.Weight = &WeightColumn{Name: c.Name, Field: len(sample.AttributeTypes)}
+		}
+	}
+	sample.NumTargets = len(sample.Targets)
+	if sample.RealTarget != "" {
+		sample.NumTargets = 1
+	}
+	sample.NumAttributes = len(sample.AttributeTypes)
+
+	return sample
+}
+
+// projection maps the columns of a line of schema data onto the attribute and
+// target fields of the .data.txt example layout
+type projection struct {
+	// fields are the column indexes of the attributes followed by the target
+	fields []int
+	width  int
+}
+
+// NewSchemaReader reads the examples of headerless data described by the schema
+// until the end of the input.
+func NewSchemaReader(reader io.Reader, s Schema, opts ParseOptions) (*Reader, error) {
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("validating schema: %w", err)
+	}
+	r := &Reader{scanner: bufio.NewScanner(reader), p: parser{opts: opts}, header: s.Sample()}
+	proj := &projection{width: len(s.Columns)}
+	var target int
+	for i, c := range s.Columns {
+		switch {
+		case c.Name == s.Target:
+			target = i
+		case c.Type == ColumnNominal || c.Type == ColumnOrdinal || c.Type == ColumnReal || c.Type == ColumnWeight:
+			proj.fields = append(proj.fields, i)
+		}
+	}
+	proj.fields = append(proj.fields, target)
+	r.projection = proj
+	if s.Header {
+		l, err := r.headerLine("column names")
+		if err != nil {
+			return nil, err
+		}
+		names := strings.Split(l.text, ",")
+		for i, name := range names {
+			if i >= len(s.Columns) || s.Columns[i].Name != name {
+				return nil, r.p.fail(l, i+1, name, "column name does not match the schema", nil)
+			}
+		}
+		if len(names) != len(s.Columns) {
+			return nil, r.p.fail(l, 0, "", fmt.Sprintf("expected %d column names, got %d", len(s.Columns), len(names)), nil)
+		}
+	}
+
+	return r, nil
+}
+
+// project rearranges a line of schema data into the example layout, returning the
+// rearranged line and the 1-based column of each of its fields.
+func (proj *projection) project(l line) (line, []int, *ParseError) {
+	splits := strings.Split(l.text, ",")
+	if len(splits) != proj.width {
+		return l, nil, &ParseError{
+			Line: l.number,
+			Msg:  fmt.Sprintf("expected %d columns, got %d", proj.width, len(splits)),
+		}
+	}
+	fields := make([]string, len(proj.fields))
+	columns := make([]int, len(proj.fields))
+	for i, column := range proj.fields {
+		fields[i] = splits[column]
+		columns[i] = column + 1
+	}
+
+	return line{number: l.number, text: strings.Join(fields, ",")}, columns, nil
+}
+
+// ParseWithSchema reads all of the headerless data described by the schema into a Sample.
+func ParseWithSchema(reader io.Reader, s Schema, opts ParseOptions) (Sample, error) {
+	r, err := NewSchemaReader(reader, s, opts)
+	if err != nil {
+		return Sample{}, err
+	}
+	return readAll(r)
+}
+
+// FromFileWithSchema parses the named headerless data file described by the schema.
+func FromFileWithSchema(filename string, s Schema, opts ParseOptions) (Sample, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Sample{}, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	defer file.Close()
+	if opts.File == "" {
+		opts.File = filename
+	}
+	return ParseWithSchema(file, s, opts)
+}
+
+// InferOptions controls schema inference.
+type InferOptions struct {
+	// Header is true when the first line names the columns
+	Header bool
+	// Target names the target column, defaulting to the last column
+	Target string
+	// Weight names a column of example weights, if there is one
+	Weight string
+	// MaxNominalValues is the most distinct values a column may have and still be
+	// proposed as nominal, defaulting to 20
+	MaxNominalValues int
+}
+
+// columnStats accumulates what inference needs to know about a column
+type columnStats struct {
+	values   []string
+	seen     map[string]bool
+	numeric  bool
+	distinct bool
+}
+
+// InferSchema scans comma-separated data and proposes a schema for it. Columns
+// with a single value are ignored. Columns with more than MaxNominalValues distinct
+// values are real when every value is a number, identifiers when every value is
+// different and otherwise ignored. Every other column is nominal with its values in
+// the order they first appear. The proposal is meant to be reviewed and edited.
+func InferSchema(reader io.Reader, opts InferOptions) (Schema, error) {
+	if opts.MaxNominalValues == 0 {
+		opts.MaxNominalValues = 20
+	}
+	s := Schema{Header: opts.Header}
+	scanner := bufio.NewScanner(reader)
+	var stats []*columnStats
+	var lineNumber, rows int
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		splits := strings.Split(text, ",")
+		if stats == nil {
+			stats = make([]*columnStats, len(splits))
+			for i := range stats {
+				stats[i] = &columnStats{seen: make(map[string]bool), numeric: true, distinct: true}
+				name := "column" + strconv.Itoa(i+1)
+				if opts.Header {
+					name = splits[i]
+				}
+				s.Columns = append(s.Columns, Column{Name: name})
+			}
+			if opts.Header {
+				continue
+			}
+		}
+		if len(splits) != len(stats) {
+			return s, &ParseError{
+				Line: lineNumber,
+				Msg:  fmt.Sprintf("expected %d columns, got %d", len(stats), len(splits)),
+			}
+		}
+		rows++
+		for i, v := range splits {
+			cs := stats[i]
+			if cs.seen[v] {
+				cs.distinct = false
+			} else {
+				cs.seen[v] = true
+				cs.values = append(cs.values, v)
+			}
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				cs.numeric = false
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return s, fmt.Errorf("reading line %d: %w", lineNumber+1, err)
+	}
+	if rows == 0 {
+		return s, fmt.Errorf("no rows to infer a schema from")
+	}
+
+	s.Target = opts.Target
+	if s.Target == "" {
+		s.Target = s.Columns[len(s.Columns)-1].Name
+	}
+	for i, cs := range stats {
+		c := &s.Columns[i]
+		switch {
+		case c.Name == s.Target:
+			c.Type, c.Values = ColumnNominal, cs.values
+		case c.Name == opts.Weight:
+			c.Type = ColumnWeight
+		case len(cs.values) == 1:
+			c.Type = ColumnIgnore
+		case len(cs.values) <= opts.MaxNominalValues:
+			c.Type, c.Values = ColumnNominal, cs.values
+		case cs.numeric:
+			c.Type = ColumnReal
+		case cs.distinct:
+			c.Type = ColumnID
+		default:
+			c.Type = ColumnIgnore
+		}
+	}
+	if err := s.Validate(); err != nil {
+		return s, fmt.Errorf("inferred schema is invalid: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,177 @@
+package parse_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromFileWithSchema(t *testing.T) {
+	s, err := parse.SchemaFromFile("../data/fishing.schema.json")
+	if err != nil {
+		t.Fatalf("reading schema: %s", err.Error())
+	}
+	fromSchema, err := parse.FromFileWithSchema("../data/fishing.csv", s, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("parsing with schema: %s", err.Error())
+	}
+	sample, err := parse.FromFile("../data/fishing.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file fishing.data.txt: %v", err)
+	}
+	// the csv has an id column and the target before the forecast, neither of which are attributes
+	if !reflect.DeepEqual(fromSchema.AttributeTypes, sample.AttributeTypes) {
+		t.Errorf("expected attribute types %v, got %v", sample.AttributeTypes, fromSchema.AttributeTypes)
+	}
+	if !reflect.DeepEqual(fromSchema.Targets, sample.Targets) {
+		t.Errorf("expected targets %v, got %v", sample.Targets, fromSchema.Targets)
+	}
+	if fromSchema.NumExamples != 14 || len(fromSchema.Examples) != 14 {
+		t.Fatalf("expected 14 examples, got %d", len(fromSchema.Examples))
+	}
+	// the csv's examples follow its column names, and the data file's its header
+	for i := range sample.Examples {
+		if fromSchema.Examples[i].Line != i+2 || sample.Examples[i].Line != i+9 {
+			t.Errorf("example %d: expected lines 2 and 9 onward, got %d and %d",
+				i, fromSchema.Examples[i].Line, sample.Examples[i].Line)
+		}
+		fromSchema.Examples[i].Line, sample.Examples[i].Line = 0, 0
+	}
+	if !reflect.DeepEqual(fromSchema.Examples, sample.Examples) {
+		t.Errorf("expected examples %v, got %v", sample.Examples, fromSchema.Examples)
+	}
+}
+
+func TestParseWithSchema_Errors(t *testing.T) {
+	s, err := parse.SchemaFromFile("../data/fishing.schema.json")
+	if err != nil {
+		t.Fatalf("reading schema: %s", err.Error())
+	}
+	data := "day,Wind,Water,Air,Outcome,Forecast\nd1,Strong,Warm,Warm,Yes,Sunny\nd2,Strong,Hot,Warm,Yes,Sunny\nd3,Weak\n"
+	_, err = parse.ParseWithSchema(strings.NewReader(data), s, parse.ParseOptions{Lenient: true})
+	var parseErrs parse.ParseErrors
+	if !errors.As(err, &parseErrs) || len(parseErrs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+	if parseErrs[0].Line != 3 || parseErrs[0].Field != 3 || parseErrs[0].Value != "Hot" {
+		t.Errorf("expected Hot in line 3 column 3, got %s", parseErrs[0].Error())
+	}
+	if parseErrs[1].Line != 4 || parseErrs[1].Field != 0 {
+		t.Errorf("expected a column count error on line 4, got %s", parseErrs[1].Error())
+	}
+
+	renamed := strings.Replace(data, "Wind", "Gust", 1)
+	_, err = parse.ParseWithSchema(strings.NewReader(renamed), s, parse.ParseOptions{})
+	var parseErr *parse.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Field != 2 {
+		t.Errorf("expected a mismatched column name in column 2, got %v", err)
+	}
+
+	truncated := strings.Replace(data, ",Forecast\n", "\n", 1)
+	_, err = parse.ParseWithSchema(strings.NewReader(truncated), s, parse.ParseOptions{})
+	if !errors.As(err, &parseErr) || parseErr.Line != 1 || !strings.Contains(parseErr.Msg, "expected 6 column names, got 5") {
+		t.Errorf("expected a missing column name on line 1, got %v", err)
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	valid := parse.Schema{
+		Target: "y",
+		Columns: []parse.Column{
+			{Name: "x", Type: parse.ColumnReal},
+			{Name: "y", Type: parse.ColumnNominal, Values: []string{"a", "b"}},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected valid schema, got %s", err.Error())
+	}
+	// a real target is predicted by a regression tree
+	regression := parse.Schema{Target: "x", Columns: valid.Columns}
+	if err := regression.Validate(); err != nil {
+		t.Errorf("expected a real target to be valid, got %s", err.Error())
+	}
+	if sample := regression.Sample(); sample.RealTarget != "x" || sample.NumTargets != 1 || len(sample.AttributeTypes) != 1 {
+		t.Errorf("expected real target x and attribute y, got %+v", sample)
+	}
+	invalid := []parse.Schema{
+		{Target: "w", Columns: append([]parse.Column{{Name: "w", Type: parse.ColumnWeight}}, valid.Columns...)},
+		{Target: "z", Columns: valid.Columns},
+		{Target: "y", Columns: append([]parse.Column{{Name: "y", Type: parse.ColumnID}}, valid.Columns...)},
+		{Target: "y", Columns: append([]parse.Column{{Name: "w", Type: "date"}}, valid.Columns...)},
+		{Target: "y", Columns: append([]parse.Column{{Name: "w", Type: parse.ColumnNominal}}, valid.Columns...)},
+		{Target: "y", Columns: append([]parse.Column{{Name: "w", Type: parse.ColumnOrdinal}}, valid.Columns...)},
+	}
+	for i, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("expected schema %d to be invalid", i)
+		}
+	}
+}
+
+func TestSchema_Ordinal(t *testing.T) {
+	s := parse.Schema{
+		Target: "y",
+		Columns: []parse.Column{
+			{Name: "size", Type: parse.ColumnOrdinal, Values: []string{"s", "m", "l"}},
+			{Name: "y", Type: parse.ColumnNominal, Values: []string{"a", "b"}},
+		},
+	}
+	sample, err := parse.ParseWithSchema(strings.NewReader("m,a\nl,b\n"), s, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("parsing with ordinal schema: %s", err.Error())
+	}
+	expected := parse.AttributeType{Name: "size", NumValues: 3, Values: []string{"s", "m", "l"}, Ordinal: true}
+	if !reflect.DeepEqual(sample.AttributeTypes[0], expected) {
+		t.Errorf("expected %+v, got %+v", expected, sample.AttributeTypes[0])
+	}
+	if _, err = parse.ParseWithSchema(strings.NewReader("xl,a\n"), s, parse.ParseOptions{}); err == nil {
+		t.Error("expected error for a value that is not in the ordinal column's values")
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	file, err := os.Open("../data/fishing.csv")
+	if err != nil {
+		t.Fatalf("opening fishing.csv: %v", err)
+	}
+	defer file.Close()
+	inferred, err := parse.InferSchema(file, parse.InferOptions{
+		Header:           true,
+		Target:           "Outcome",
+		MaxNominalValues: 5,
+	})
+	if err != nil {
+		t.Fatalf("inferring schema: %s", err.Error())
+	}
+	expected, err := parse.SchemaFromFile("../data/fishing.schema.json")
+	if err != nil {
+		t.Fatalf("reading schema: %s", err.Error())
+	}
+	// values are proposed in the order they first appear
+	expected.Columns[2].Values = []string{"Warm", "Moderate", "Cold"}
+	expected.Columns[5].Values = []string{"Sunny", "Cloudy", "Rainy"}
+	if !reflect.DeepEqual(inferred, expected) {
+		var buf bytes.Buffer
+		inferred.Write(&buf)
+		t.Errorf("unexpected inferred schema:\n%s", buf.String())
+	}
+
+	numbers := "1,a,x,yes\n2.5,b,x,no\n3,c,x,yes\n4,d,x,no\n"
+	inferred, err = parse.InferSchema(strings.NewReader(numbers), parse.InferOptions{MaxNominalValues: 3})
+	if err != nil {
+		t.Fatalf("inferring schema: %s", err.Error())
+	}
+	types := []string{parse.ColumnReal, parse.ColumnID, parse.ColumnIgnore, parse.ColumnNominal}
+	for i, c := range inferred.Columns {
+		if c.Type != types[i] {
+			t.Errorf("column %d: expected %s, got %s", i+1, types[i], c.Type)
+		}
+	}
+	if inferred.Target != "column4" {
+		t.Errorf("expected the last column to be the target, got %s", inferred.Target)
+	}
+}
@@ -10,8 +10,22 @@ type Sample struct {
 	Targets        Targets
 	NumAttributes  int
 	AttributeTypes AttributeTypes
-	NumExamples    int
-	Examples       Examples
+	// Weight describes the column holding example weights, or is nil when examples are unweighted
+	Weight *WeightColumn
+	// RealTarget names a real-valued target, declared on the targets line as
+	// "<name>,real", and is empty when each example has one of Targets. Examples
+	// of a real target hold it in Value and leave Target empty.
+	RealTarget  string `json:",omitempty"`
+	NumExamples int
+	Examples    Examples
+}
+
+// WeightColumn is an example field that holds the example's weight rather than an
+// attribute value. It is declared among the attribute types as "<name>,weight".
+type WeightColumn struct {
+	Name string
+	// Field is the 0-based position of the weight among the fields of an example
+	Field int
 }
 
 // Filter takes the given attribute name and value and filters the Sample to reflect only this,
@@ -35,12 +49,14 @@ func (s Sample) Filter(attrName, attrValue string) (Sample, error) {
 			remainingTargetSet[eg.Target] = true
 		}
 	}
-	// reset targets list
-	sample.Targets = make(Targets, 0)
-	for target := range remainingTargetSet {
-		sample.Targets = append(sample.Targets, target)
+	// reset targets list, which a real target does not have
+	if sample.RealTarget == "" {
+		sample.Targets = make(Targets, 0)
+		for target := range remainingTargetSet {
+			sample.Targets = append(sample.Targets, target)
+		}
+		sample.NumTargets = len(sample.Targets)
 	}
-	sample.NumTargets = len(sample.Targets)
 	at, err := sample.AttributeTypes.Delete(attrName)
 	if err != nil {
 		return sample, fmt.Errorf("filtering by %s, value %s: %w",
@@ -48,6 +64,15 @@ func (s Sample) Filter(attrName, attrValue string) (Sample, error) {
 	}
 	sample.AttributeTypes = at
 	sample.NumAttributes = sample.NumAttributes - 1
+	if sample.Weight != nil {
+		// copy the weight column so the original sample's is left alone, moving it back
+		// a field when the deleted attribute came before it
+		weight := *sample.Weight
+		if attrIndex < weight.Field {
+			weight.Field--
+		}
+		sample.Weight = &weight
+	}
 	sample.Examples = filteredExamples
 	sample.NumExamples = len(sample.Examples)
 	fmt.Printf("post-filter attribute type names and values:\n%s", sample.AttributeTypes.TerminalSummary())
@@ -155,6 +180,8 @@ type AttributeType struct {
 	NumValues int
 	Values    []string
 	Real      bool
+	// Ordinal marks a nominal attribute whose values are declared in order
+	Ordinal bool
 }
 
 func (at AttributeType) IsValidValue(value string) bool {
@@ -168,11 +195,15 @@ func (at AttributeType) IsValidValue(value string) bool {
 }
 
 // OccurrencesInTargets returns an AttributeOccurrenceLookup with method
-// AttributeValueTotal(attrValue string) int
+// AttributeValueTotal(attrValue string) float64. Each example counts by its weight.
+// Real attributes have no values to count, and a real target no targets to count by.
 func (at AttributeType) OccurrencesInTargets(s Sample) (AttributeOccurrenceLookup, error) {
 	lookup := make(AttributeOccurrenceLookup)
 	for _, value := range at.Values {
-		lookup[value] = make([]int, s.NumTargets)
+		lookup[value] = make([]float64, len(s.Targets))
+	}
+	if at.Real || s.RealTarget != "" {
+		return lookup, fmt.Errorf("counting %s by target: real attributes and targets have no occurrences", at.Name)
 	}
 
 	attrIndex, err := s.AttributeTypes.Index(at.Name)
@@ -180,6 +211,12 @@ func (at AttributeType) OccurrencesInTargets(s Sample) (AttributeOccurrenceLooku
 		return lookup, fmt.Errorf("finding attribute type %s: %w",
 			at.Name, err)
 	}
+	// StringValues holds only the values of nominal attributes
+	for _, other := range s.AttributeTypes[:attrIndex] {
+		if other.Real {
+			attrIndex--
+		}
+	}
 	for _, eg := range s.Examples {
 		attrValue := eg.StringValues[attrIndex]
 		targetIndex, err := s.Targets.Index(eg.Target)
@@ -187,23 +224,27 @@ func (at AttributeType) OccurrencesInTargets(s Sample) (AttributeOccurrenceLooku
 			return lookup, fmt.Errorf("finding target %s: %w",
 				eg.Target, err)
 		}
-		lookup[attrValue][targetIndex] += 1
+		occurrences, ok := lookup[attrValue]
+		if !ok {
+			return lookup, fmt.Errorf("counting %s by target: value %s is not declared", at.Name, attrValue)
+		}
+		occurrences[targetIndex] += eg.EffectiveWeight()
 	}
 
 	return lookup, nil
 }
 
-// AttributeOccurrenceLookup is a map of attribute value to slice of int.
-// slice of target occurrences
+// AttributeOccurrenceLookup is a map of attribute value to slice of float64.
+// slice of weighted target occurrences
 //  {
 //    '<25': [1, 0],
 //    '25-40': [1, 0],
 //    '>40': [1, 2]
 //  }
-type AttributeOccurrenceLookup map[string][]int
+type AttributeOccurrenceLookup map[string][]float64
 
-func (aom AttributeOccurrenceLookup) AttributeValueTotal(attrValue string) int {
-	var total int
+func (aom AttributeOccurrenceLookup) AttributeValueTotal(attrValue string) float64 {
+	var total float64
 	targetOccurrences := aom[attrValue]
 
 	for _, value := range targetOccurrences {
@@ -219,6 +260,22 @@ type Example struct {
 	StringValues []string
 	RealValues   []float64
 	Target       string
+	// Value is the example's target when the sample has a real target
+	Value float64 `json:",omitempty"`
+	// Weight is the example's importance, or zero for an unweighted example
+	Weight float64
+	// Line is the 1-based line of the data file the example was read from, or zero
+	// for an example that was not parsed
+	Line int `json:",omitempty"`
+}
+
+// EffectiveWeight returns the example's weight, counting an unweighted example as one.
+func (eg Example) EffectiveWeight() float64 {
+	if eg.Weight == 0 {
+		return 1
+	}
+
+	return eg.Weight
 }
 
 func (eg Example) DeleteValue(index int) Example {
@@ -228,3 +285,18 @@ func (eg Example) DeleteValue(index int) Example {
 
 	return eg
 }
+
+// ExampleValues maps each attribute type name to the example's value for it. Real
+// values are formatted in the shortest representation that parses back exactly.
+func (at AttributeTypes) ExampleValues(eg Example) (map[string]string, error) {
+	fields, err := at.exampleFields(eg)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(at))
+	for i, attrType := range at {
+		values[attrType.Name] = fields[i]
+	}
+
+	return values, nil
+}
@@ -2,6 +2,7 @@ package parse_test
 
 import (
 	"github.com/PaluMacil/decisive-oak/parse"
+	"strings"
 	"testing"
 )
 
@@ -77,7 +78,7 @@ func Test_AttributeOccurrenceLookup_AttributeValueTotal(t *testing.T) {
 	}
 }
 
-func checkTotal(sample parse.Sample, attrTypeName, attrValue string, t *testing.T) int {
+func checkTotal(sample parse.Sample, attrTypeName, attrValue string, t *testing.T) float64 {
 	idx, err := sample.AttributeTypes.Index(attrTypeName)
 	if err != nil {
 		t.Errorf("checking total: %s", err.Error())
@@ -215,3 +216,52 @@ var attributeTypes = parse.AttributeTypes{
 		Real: false,
 	},
 }
+
+func Test_AttributeType_OccurrencesInTargets_Weighted(t *testing.T) {
+	sample, err := parse.FromFile("../data/new-treatment.data.txt")
+	if err != nil {
+		t.Errorf("failed parsing file new-treatment.data.txt: %v", err)
+	}
+	// age >40 has one pos and two neg examples
+	sample.Examples[2].Weight = 0.5
+	sample.Examples[3].Weight = 3
+	lookup, err := sample.AttributeTypes[2].OccurrencesInTargets(sample)
+	if err != nil {
+		t.Errorf("getting occurrences in targets: %s", err.Error())
+	}
+	if lookup[">40"][0] != 0.5 || lookup[">40"][1] != 4 {
+		t.Errorf("expected weighted occurrences [0.5 4], got %v", lookup[">40"])
+	}
+	if lookup.AttributeValueTotal(">40") != 4.5 {
+		t.Errorf("expected weighted total 4.5, got %v", lookup.AttributeValueTotal(">40"))
+	}
+}
+
+func Test_AttributeType_OccurrencesInTargets_AfterReal(t *testing.T) {
+	const data = `2
+low,high
+3
+weight,real
+colour,2,red,blue
+size,2,s,l
+3
+0.1,red,s,low
+2.5,blue,l,high
+1.5,blue,s,high
+`
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing real-valued sample: %s", err.Error())
+	}
+	// size is the second nominal value of each example, though the third attribute
+	lookup, err := sample.AttributeTypes[2].OccurrencesInTargets(sample)
+	if err != nil {
+		t.Fatalf("getting occurrences in targets: %s", err.Error())
+	}
+	if lookup["s"][0] != 1 || lookup["s"][1] != 1 || lookup["l"][1] != 1 {
+		t.Errorf("expected s to be low once and high once and l high once, got %v", lookup)
+	}
+	if _, err = sample.AttributeTypes[0].OccurrencesInTargets(sample); err == nil {
+		t.Error("expected an error for a real attribute")
+	}
+}
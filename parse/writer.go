@@ -0,0 +1,138 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ToFile writes the sample to the named file in the .data.txt format.
+func ToFile(filename string, sample Sample) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", filename, err)
+	}
+	if err = Write(file, sample); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", filename, err)
+	}
+	return file.Close()
+}
+
+// Write encodes the sample in the count-prefixed .data.txt layout read by Parse:
+// the targets, the attribute declarations and then the examples, each preceded by
+// its count. Counts are taken from the lengths of the slices rather than the
+// sample's Num fields so that the output always parses. Example weights are only
+// written when the sample declares a weight column, and a real target is declared
+// as "<name>,real".
+func Write(w io.Writer, sample Sample) error {
+	bw := bufio.NewWriter(w)
+	if sample.RealTarget != "" {
+		if err := checkFields("target name", []string{sample.RealTarget}); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "1\n%s,real\n", sample.RealTarget)
+	} else {
+		if err := checkFields("target", sample.Targets); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "%d\n%s\n", len(sample.Targets), strings.Join(sample.Targets, ","))
+	}
+
+	numDeclarations := len(sample.AttributeTypes)
+	if sample.Weight != nil {
+		numDeclarations++
+		if err := checkFields("weight name", []string{sample.Weight.Name}); err != nil {
+			return err
+		}
+		if sample.Weight.Field < 0 || sample.Weight.Field >= numDeclarations {
+			return fmt.Errorf("weight field %d is out of range", sample.Weight.Field)
+		}
+	}
+	fmt.Fprintf(bw, "%d\n", numDeclarations)
+	for i, at := range sample.AttributeTypes {
+		if sample.Weight != nil && sample.Weight.Field == i {
+			fmt.Fprintf(bw, "%s,weight\n", sample.Weight.Name)
+		}
+		if err := checkFields("attribute name", []string{at.Name}); err != nil {
+			return err
+		}
+		if at.Real {
+			fmt.Fprintf(bw, "%s,real\n", at.Name)
+			continue
+		}
+		if err := checkFields("value of "+at.Name, at.Values); err != nil {
+			return err
+		}
+		if at.Ordinal {
+			fmt.Fprintf(bw, "%s,ordinal,%d,%s\n", at.Name, len(at.Values), strings.Join(at.Values, ","))
+			continue
+		}
+		fmt.Fprintf(bw, "%s,%d,%s\n", at.Name, len(at.Values), strings.Join(at.Values, ","))
+	}
+	if sample.Weight != nil && sample.Weight.Field == len(sample.AttributeTypes) {
+		fmt.Fprintf(bw, "%s,weight\n", sample.Weight.Name)
+	}
+
+	fmt.Fprintf(bw, "%d\n", len(sample.Examples))
+	for i, eg := range sample.Examples {
+		fields, err := sample.AttributeTypes.exampleFields(eg)
+		if err != nil {
+			return fmt.Errorf("writing example %d: %w", i, err)
+		}
+		if sample.Weight != nil {
+			weight := strconv.FormatFloat(eg.EffectiveWeight(), 'g', -1, 64)
+			fields = append(fields[:sample.Weight.Field], append([]string{weight}, fields[sample.Weight.Field:]...)...)
+		}
+		if sample.RealTarget != "" {
+			fields = append(fields, strconv.FormatFloat(eg.Value, 'g', -1, 64))
+		} else {
+			fields = append(fields, eg.Target)
+		}
+		if err := checkFields(fmt.Sprintf("field of example %d", i), fields); err != nil {
+			return err
+		}
+		bw.WriteString(strings.Join(fields, ","))
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
+
+// exampleFields returns the example's values in attribute type order, formatting
+// real values in the shortest form that parses back to the same number.
+func (at AttributeTypes) exampleFields(eg Example) ([]string, error) {
+	fields := make([]string, 0, len(at))
+	var iString, iReal int
+	for _, attrType := range at {
+		if attrType.Real {
+			if iReal >= len(eg.RealValues) {
+				return fields, fmt.Errorf("missing real value for %s", attrType.Name)
+			}
+			fields = append(fields, strconv.FormatFloat(eg.RealValues[iReal], 'g', -1, 64))
+			iReal++
+			continue
+		}
+		if iString >= len(eg.StringValues) {
+			return fields, fmt.Errorf("missing value for %s", attrType.Name)
+		}
+		fields = append(fields, eg.StringValues[iString])
+		iString++
+	}
+
+	return fields, nil
+}
+
+// checkFields ensures that none of the values would change the layout of a line
+func checkFields(kind string, values []string) error {
+	for _, v := range values {
+		if v == "" || strings.ContainsAny(v, ",\r\n") {
+			return fmt.Errorf("%s %q cannot be written: values must be non-empty without commas or line breaks", kind, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package parse_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func roundTrip(sample parse.Sample, t *testing.T) parse.Sample {
+	var buf bytes.Buffer
+	if err := parse.Write(&buf, sample); err != nil {
+		t.Fatalf("writing sample: %s", err.Error())
+	}
+	parsed, err := parse.Parse(&buf)
+	if err != nil {
+		t.Fatalf("parsing written sample: %s", err.Error())
+	}
+
+	return parsed
+}
+
+func TestWrite_RoundTripBundled(t *testing.T) {
+	files, err := filepath.Glob("../data/*.data.txt")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("finding data files: %v", err)
+	}
+	for _, filename := range files {
+		sample, err := parse.FromFile(filename)
+		if err != nil {
+			t.Fatalf("failed parsing file %s: %v", filename, err)
+		}
+		if parsed := roundTrip(sample, t); !reflect.DeepEqual(parsed, sample) {
+			t.Errorf("%s did not round trip:\nexpected %+v\ngot %+v", filename, sample, parsed)
+		}
+	}
+}
+
+func TestWrite_RoundTripReal(t *testing.T) {
+	const data = `2
+low,high
+3
+colour,2,red,blue
+weight,real
+size,2,s,l
+3
+red,0.1,s,low
+blue,-2.5e-7,l,high
+blue,1234567.0000001,s,high
+`
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing real-valued sample: %s", err.Error())
+	}
+	if parsed := roundTrip(sample, t); !reflect.DeepEqual(parsed, sample) {
+		t.Errorf("real-valued sample did not round trip:\nexpected %+v\ngot %+v", sample, parsed)
+	}
+}
+
+func TestWrite_RoundTripOrdinal(t *testing.T) {
+	const data = `2
+low,high
+2
+size,ordinal,3,s,m,l
+colour,2,red,blue
+2
+s,red,low
+l,blue,high
+`
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing ordinal sample: %s", err.Error())
+	}
+	if at := sample.AttributeTypes[0]; !at.Ordinal || !reflect.DeepEqual(at.Values, []string{"s", "m", "l"}) {
+		t.Errorf("expected ordinal size with values s, m, l, got %+v", at)
+	}
+	if sample.AttributeTypes[1].Ordinal {
+		t.Error("expected colour not to be ordinal")
+	}
+	if parsed := roundTrip(sample, t); !reflect.DeepEqual(parsed, sample) {
+		t.Errorf("ordinal sample did not round trip:\nexpected %+v\ngot %+v", sample, parsed)
+	}
+	_, err = parse.Parse(strings.NewReader(strings.Replace(data, "ordinal,3", "ordinal,three", 1)))
+	var parseErr *parse.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Field != 3 {
+		t.Errorf("expected an error in field 3 for a bad ordinal value count, got %v", err)
+	}
+}
+
+func TestWrite_RoundTripRealTarget(t *testing.T) {
+	const data = `1
+price,real
+2
+size,ordinal,3,s,m,l
+weight,real
+3
+s,0.5,1.25
+m,1,-3
+l,2,1e+06
+`
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing real target sample: %s", err.Error())
+	}
+	if sample.RealTarget != "price" || sample.Targets != nil || sample.Examples[2].Value != 1e6 {
+		t.Errorf("expected real target price with values, got %+v", sample)
+	}
+	if parsed := roundTrip(sample, t); !reflect.DeepEqual(parsed, sample) {
+		t.Errorf("real target sample did not round trip:\nexpected %+v\ngot %+v", sample, parsed)
+	}
+	_, err = parse.Parse(strings.NewReader(strings.Replace(data, "1e+06", "lots", 1)))
+	var parseErr *parse.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Field != 3 {
+		t.Errorf("expected an error in field 3 for a bad target value, got %v", err)
+	}
+}
+
+func TestWrite_Filtered(t *testing.T) {
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	young, err := sample.Filter("age", "young")
+	if err != nil {
+		t.Fatalf("filtering on age, young: %s", err.Error())
+	}
+	parsed := roundTrip(young, t)
+	if len(parsed.Examples) != 8 || len(parsed.AttributeTypes) != 3 {
+		t.Errorf("expected 8 examples of 3 attributes, got %d of %d",
+			len(parsed.Examples), len(parsed.AttributeTypes))
+	}
+}
+
+func TestWrite_FilteredWeighted(t *testing.T) {
+	const data = `2
+pos,neg
+3
+pulse,2,normal,rapid
+w,weight
+bp,2,normal,high
+3
+normal,2.5,normal,pos
+rapid,0.25,high,neg
+normal,1,high,neg
+`
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing weighted sample: %s", err.Error())
+	}
+	tests := []struct {
+		attribute, value string
+		field            int
+		weights          []float64
+	}{
+		// the weight moves forward when the attribute before it is deleted
+		{"pulse", "normal", 0, []float64{2.5, 1}},
+		// and ends up last when the attribute after it is
+		{"bp", "high", 1, []float64{0.25, 1}},
+	}
+	for _, tt := range tests {
+		filtered, err := sample.Filter(tt.attribute, tt.value)
+		if err != nil {
+			t.Fatalf("filtering on %s, %s: %s", tt.attribute, tt.value, err.Error())
+		}
+		parsed := roundTrip(filtered, t)
+		if parsed.Weight == nil || parsed.Weight.Field != tt.field {
+			t.Errorf("%s: expected the weight in field %d, got %+v", tt.attribute, tt.field, parsed.Weight)
+		}
+		for i, eg := range parsed.Examples {
+			if eg.Weight != tt.weights[i] {
+				t.Errorf("%s: expected example %d to weigh %g, got %g", tt.attribute, i, tt.weights[i], eg.Weight)
+			}
+		}
+	}
+	if sample.Weight.Field != 1 {
+		t.Errorf("expected filtering to leave the original weight in field 1, got %d", sample.Weight.Field)
+	}
+}
+
+func TestWrite_InvalidValue(t *testing.T) {
+	sample := parse.Sample{
+		Targets:        parse.Targets{"a,b"},
+		AttributeTypes: attributeTypes,
+	}
+	if err := parse.Write(&bytes.Buffer{}, sample); err == nil {
+		t.Error("expected error writing a target containing a comma")
+	}
+}
+
+func TestWrite_RoundTripWeighted(t *testing.T) {
+	const data = `2
+pos,neg
+3
+pulse,2,normal,rapid
+w,weight
+bp,2,normal,high
+3
+normal,2.5,normal,pos
+rapid,0.25,high,neg
+normal,1,high,neg
+`
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing weighted sample: %s", err.Error())
+	}
+	if sample.NumAttributes != 2 || sample.Weight == nil || sample.Weight.Field != 1 {
+		t.Fatalf("expected 2 attributes and a weight in field 1, got %d and %+v", sample.NumAttributes, sample.Weight)
+	}
+	if sample.Examples[0].Weight != 2.5 || sample.Examples[1].StringValues[1] != "high" {
+		t.Errorf("unexpected examples %+v", sample.Examples)
+	}
+	if parsed := roundTrip(sample, t); !reflect.DeepEqual(parsed, sample) {
+		t.Errorf("weighted sample did not round trip:\nexpected %+v\ngot %+v", sample, parsed)
+	}
+
+	for _, weight := range []string{"0", "-1", "NaN", "+Inf", "heavy"} {
+		invalid := strings.Replace(data, "rapid,0.25", "rapid,"+weight, 1)
+		if _, err := parse.Parse(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected weight %s to be invalid", weight)
+		}
+	}
+}
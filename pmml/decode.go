@@ -0,0 +1,209 @@
+package pmml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io"
+	"strings"
+)
+
+// Decode reads a PMML document containing a TreeModel whose splits are equality or
+// set membership tests on categorical fields or range tests on ordinal fields, as
+// written by Encode.
+func Decode(r io.Reader) (Model, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Model{}, fmt.Errorf("decoding PMML document: %w", err)
+	}
+	if doc.TreeModel == nil {
+		return Model{}, fmt.Errorf("PMML document has no TreeModel")
+	}
+	if doc.TreeModel.FunctionName != "classification" {
+		return Model{}, fmt.Errorf("unsupported TreeModel function %s", doc.TreeModel.FunctionName)
+	}
+	m := Model{Name: doc.TreeModel.ModelName}
+	for _, mf := range doc.TreeModel.MiningSchema.MiningFields {
+		if mf.UsageType == "target" || mf.UsageType == "predicted" {
+			m.TargetName = mf.Name
+		}
+	}
+	if m.TargetName == "" {
+		return Model{}, fmt.Errorf("mining schema has no target field")
+	}
+	for _, field := range doc.DataDictionary.DataFields {
+		values := make([]string, len(field.Values))
+		for i, v := range field.Values {
+			values[i] = v.Value
+		}
+		if field.Name == m.TargetName {
+			m.Targets = values
+			continue
+		}
+		at := parse.AttributeType{Name: field.Name}
+		if field.OpType == "continuous" {
+			at.Real = true
+		} else {
+			at.NumValues = len(values)
+			at.Values = values
+			at.Ordinal = field.OpType == "ordinal"
+		}
+		m.AttributeTypes = append(m.AttributeTypes, at)
+	}
+
+	tree, err := decodeNode(doc.TreeModel.Node, m)
+	if err != nil {
+		return Model{}, fmt.Errorf("decoding tree: %w", err)
+	}
+	m.Tree = tree
+
+	return m, nil
+}
+
+func decodeNode(n node, m Model) (analysis.Node, error) {
+	decoded := analysis.Node{}
+	if n.SimplePredicate != nil {
+		if err := decodePredicate(&decoded, *n.SimplePredicate, m); err != nil {
+			return decoded, fmt.Errorf("node %s: %w", n.ID, err)
+		}
+	}
+	if n.SimpleSetPredicate != nil {
+		if err := decodeSetPredicate(&decoded, *n.SimpleSetPredicate); err != nil {
+			return decoded, fmt.Errorf("node %s: %w", n.ID, err)
+		}
+	}
+	if n.RecordCount != nil || len(n.ScoreDistribution) > 0 {
+		decoded.TargetCounts = make(map[string]float64)
+		for _, sd := range n.ScoreDistribution {
+			decoded.TargetCounts[sd.Value] = sd.RecordCount
+		}
+	}
+	if len(n.Nodes) == 0 {
+		if !m.Targets.IsValid(n.Score) {
+			return decoded, fmt.Errorf("leaf %s has score %s which is not a target", n.ID, n.Score)
+		}
+		decoded.Label = n.Score
+		decoded.Terminal = true
+		return decoded, nil
+	}
+	for _, child := range n.Nodes {
+		var field string
+		switch {
+		case child.SimplePredicate != nil:
+			field = child.SimplePredicate.Field
+		case child.SimpleSetPredicate != nil:
+			field = child.SimpleSetPredicate.Field
+		default:
+			return decoded, fmt.Errorf("child of node %s has no simple predicate", n.ID)
+		}
+		if decoded.Label == "" {
+			decoded.Label = field
+		} else if decoded.Label != field {
+			return decoded, fmt.Errorf("children of node %s split on both %s and %s",
+				n.ID, decoded.Label, field)
+		}
+		decodedChild, err := decodeNode(child, m)
+		if err != nil {
+			return decoded, err
+		}
+		decoded.Children = append(decoded.Children, decodedChild)
+	}
+	if !m.AttributeTypes.IsValid(decoded.Label) {
+		return decoded, fmt.Errorf("node %s splits on unknown field %s", n.ID, decoded.Label)
+	}
+
+	return decoded, nil
+}
+
+// decodePredicate sets the filter of a node from its predicate, listing the values
+// a range admits in the order the data dictionary gives them.
+func decodePredicate(decoded *analysis.Node, p simplePredicate, m Model) error {
+	decoded.FilterValue = p.Value
+	for op, operator := range operators {
+		if operator == p.Operator {
+			decoded.FilterOp = op
+			if op == "" {
+				return nil
+			}
+			i, err := m.AttributeTypes.Index(p.Field)
+			if err != nil {
+				return fmt.Errorf("range predicate on unknown field %s", p.Field)
+			}
+			at := m.AttributeTypes[i]
+			if !at.Ordinal {
+				return fmt.Errorf("range predicate on field %s, which is not ordinal", p.Field)
+			}
+			boundary := -1
+			for iValue, v := range at.Values {
+				if v == p.Value {
+					boundary = iValue
+				}
+			}
+			if boundary < 0 {
+				return fmt.Errorf("range predicate on unknown value %s of %s", p.Value, p.Field)
+			}
+			if op == "<=" {
+				decoded.FilterValues = at.Values[:boundary+1]
+			} else {
+				decoded.FilterValues = at.Values[boundary+1:]
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported predicate operator %s", p.Operator)
+}
+
+// decodeSetPredicate sets the filter of a node from an isIn set predicate.
+func decodeSetPredicate(decoded *analysis.Node, p simpleSetPredicate) error {
+	if p.BooleanOperator != "isIn" {
+		return fmt.Errorf("unsupported set predicate operator %s", p.BooleanOperator)
+	}
+	values, err := decodeArray(p.Array.Values)
+	if err != nil {
+		return fmt.Errorf("set predicate on %s: %w", p.Field, err)
+	}
+	if p.Array.N != 0 && p.Array.N != len(values) {
+		return fmt.Errorf("set predicate on %s has %d values, expected %d", p.Field, len(values), p.Array.N)
+	}
+	decoded.FilterOp = "in"
+	decoded.FilterValues = values
+	decoded.FilterValue = strings.Join(values, ", ")
+
+	return nil
+}
+
+// decodeArray splits the contents of a string Array into its values, which are
+// separated by whitespace and may be quoted, with backslash escapes inside quotes.
+func decodeArray(text string) ([]string, error) {
+	var values []string
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				sb.WriteByte(text[i])
+			}
+			if i >= len(text) {
+				return nil, fmt.Errorf("unterminated quoted value in array")
+			}
+			i++
+			values = append(values, sb.String())
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\n\r", rune(text[i])) {
+				i++
+			}
+			values = append(values, text[start:i])
+		}
+	}
+
+	return values, nil
+}
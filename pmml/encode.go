@@ -0,0 +1,167 @@
+package pmml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Encode writes the model as a PMML TreeModel document.
+func Encode(w io.Writer, m Model) error {
+	targetName := m.TargetName
+	if targetName == "" {
+		targetName = DefaultTargetName
+	}
+	if m.AttributeTypes.IsValid(targetName) {
+		return fmt.Errorf("target name %s collides with an attribute name", targetName)
+	}
+	doc := document{
+		Namespace: Namespace,
+		Version:   Version,
+		Header:    header{Application: application{Name: "decisive-oak"}},
+	}
+	var schema miningSchema
+	for _, at := range m.AttributeTypes {
+		field := dataField{Name: at.Name}
+		if at.Real {
+			field.OpType, field.DataType = "continuous", "double"
+		} else {
+			field.OpType, field.DataType = "categorical", "string"
+			if at.Ordinal {
+				// the order of the values gives range predicates their meaning
+				field.OpType = "ordinal"
+			}
+			for _, v := range at.Values {
+				field.Values = append(field.Values, value{Value: v})
+			}
+		}
+		doc.DataDictionary.DataFields = append(doc.DataDictionary.DataFields, field)
+		schema.MiningFields = append(schema.MiningFields, miningField{Name: at.Name})
+	}
+	target := dataField{Name: targetName, OpType: "categorical", DataType: "string"}
+	for _, t := range m.Targets {
+		target.Values = append(target.Values, value{Value: t})
+	}
+	doc.DataDictionary.DataFields = append(doc.DataDictionary.DataFields, target)
+	doc.DataDictionary.NumberOfFields = len(doc.DataDictionary.DataFields)
+	schema.MiningFields = append(schema.MiningFields, miningField{Name: targetName, UsageType: "target"})
+
+	root, err := encodeNode(m.Tree, m, nil, new(int))
+	if err != nil {
+		return fmt.Errorf("encoding tree: %w", err)
+	}
+	doc.TreeModel = &treeModel{
+		ModelName:            m.Name,
+		FunctionName:         "classification",
+		SplitCharacteristic:  "multiSplit",
+		MissingValueStrategy: "none",
+		NoTrueChildStrategy:  "returnNullPrediction",
+		MiningSchema:         schema,
+		Node:                 root,
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing XML header: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encoding PMML document: %w", err)
+	}
+
+	return nil
+}
+
+// operators maps node filter operators to PMML predicate operators
+var operators = map[string]string{
+	"":   "equal",
+	"<=": "lessOrEqual",
+	">":  "greaterThan",
+}
+
+func encodeNode(n analysis.Node, m Model, parent *analysis.Node, lastID *int) (node, error) {
+	*lastID++
+	encoded := node{ID: strconv.Itoa(*lastID)}
+	if parent == nil {
+		encoded.True = &struct{}{}
+	} else if n.FilterOp == "in" {
+		encoded.SimpleSetPredicate = &simpleSetPredicate{
+			Field:           parent.Label,
+			BooleanOperator: "isIn",
+			Array:           encodeArray(n.FilterValues),
+		}
+	} else {
+		operator, ok := operators[n.FilterOp]
+		if !ok {
+			return encoded, fmt.Errorf("unknown filter operator %s", n.FilterOp)
+		}
+		encoded.SimplePredicate = &simplePredicate{
+			Field:    parent.Label,
+			Operator: operator,
+			Value:    n.FilterValue,
+		}
+	}
+	var total float64
+	for _, t := range m.Targets {
+		count, ok := n.TargetCounts[t]
+		if !ok {
+			continue
+		}
+		encoded.ScoreDistribution = append(encoded.ScoreDistribution, scoreDistribution{
+			Value:       t,
+			RecordCount: count,
+		})
+		total += count
+	}
+	if n.TargetCounts != nil {
+		encoded.RecordCount = &total
+	}
+	if n.Terminal {
+		if !m.Targets.IsValid(n.Label) {
+			return encoded, fmt.Errorf("leaf label %s is not a target", n.Label)
+		}
+		encoded.Score = n.Label
+		return encoded, nil
+	}
+	if !m.AttributeTypes.IsValid(n.Label) {
+		return encoded, fmt.Errorf("split attribute %s is not an attribute type", n.Label)
+	}
+	encoded.Score = majority(encoded.ScoreDistribution)
+	for i := range n.Children {
+		child, err := encodeNode(n.Children[i], m, &n, lastID)
+		if err != nil {
+			return encoded, err
+		}
+		encoded.Nodes = append(encoded.Nodes, child)
+	}
+
+	return encoded, nil
+}
+
+// encodeArray quotes each value, escaping the quotes and backslashes within it.
+func encodeArray(values []string) array {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+		quoted[i] = `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+
+	return array{N: len(values), Type: "string", Values: strings.Join(quoted, " ")}
+}
+
+// majority returns the value with the highest record count, preferring the
+// earliest value on ties.
+func majority(distribution []scoreDistribution) string {
+	var best string
+	var bestCount float64
+	for _, sd := range distribution {
+		if best == "" || sd.RecordCount > bestCount {
+			best, bestCount = sd.Value, sd.RecordCount
+		}
+	}
+
+	return best
+}
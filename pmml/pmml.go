@@ -0,0 +1,112 @@
+// Package pmml reads and writes decision trees as PMML 4.4 TreeModel documents
+// so that they can be exchanged with other modelling tools.
+package pmml
+
+import (
+	"encoding/xml"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+)
+
+const (
+	Version   = "4.4"
+	Namespace = "http://www.dmg.org/PMML-4_4"
+	// DefaultTargetName names the target field, since the data format does not name it
+	DefaultTargetName = "class"
+)
+
+// Model is a tree together with the schema needed to describe it in PMML.
+type Model struct {
+	Name           string
+	TargetName     string
+	Targets        parse.Targets
+	AttributeTypes parse.AttributeTypes
+	Tree           analysis.Node
+}
+
+type document struct {
+	XMLName        xml.Name       `xml:"PMML"`
+	Namespace      string         `xml:"xmlns,attr"`
+	Version        string         `xml:"version,attr"`
+	Header         header         `xml:"Header"`
+	DataDictionary dataDictionary `xml:"DataDictionary"`
+	TreeModel      *treeModel     `xml:"TreeModel"`
+}
+
+type header struct {
+	Application application `xml:"Application"`
+}
+
+type application struct {
+	Name string `xml:"name,attr"`
+}
+
+type dataDictionary struct {
+	NumberOfFields int         `xml:"numberOfFields,attr"`
+	DataFields     []dataField `xml:"DataField"`
+}
+
+type dataField struct {
+	Name     string  `xml:"name,attr"`
+	OpType   string  `xml:"optype,attr"`
+	DataType string  `xml:"dataType,attr"`
+	Values   []value `xml:"Value"`
+}
+
+type value struct {
+	Value string `xml:"value,attr"`
+}
+
+type treeModel struct {
+	ModelName            string       `xml:"modelName,attr,omitempty"`
+	FunctionName         string       `xml:"functionName,attr"`
+	SplitCharacteristic  string       `xml:"splitCharacteristic,attr"`
+	MissingValueStrategy string       `xml:"missingValueStrategy,attr"`
+	NoTrueChildStrategy  string       `xml:"noTrueChildStrategy,attr"`
+	MiningSchema         miningSchema `xml:"MiningSchema"`
+	Node                 node         `xml:"Node"`
+}
+
+type miningSchema struct {
+	MiningFields []miningField `xml:"MiningField"`
+}
+
+type miningField struct {
+	Name      string `xml:"name,attr"`
+	UsageType string `xml:"usageType,attr,omitempty"`
+}
+
+type node struct {
+	ID                 string              `xml:"id,attr,omitempty"`
+	Score              string              `xml:"score,attr,omitempty"`
+	RecordCount        *float64            `xml:"recordCount,attr"`
+	True               *struct{}           `xml:"True"`
+	SimplePredicate    *simplePredicate    `xml:"SimplePredicate"`
+	SimpleSetPredicate *simpleSetPredicate `xml:"SimpleSetPredicate"`
+	ScoreDistribution  []scoreDistribution `xml:"ScoreDistribution"`
+	Nodes              []node              `xml:"Node"`
+}
+
+type simplePredicate struct {
+	Field    string `xml:"field,attr"`
+	Operator string `xml:"operator,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+type simpleSetPredicate struct {
+	Field           string `xml:"field,attr"`
+	BooleanOperator string `xml:"booleanOperator,attr"`
+	Array           array  `xml:"Array"`
+}
+
+// array holds whitespace-separated values, each quoted when written
+type array struct {
+	N      int    `xml:"n,attr"`
+	Type   string `xml:"type,attr"`
+	Values string `xml:",chardata"`
+}
+
+type scoreDistribution struct {
+	Value       string  `xml:"value,attr"`
+	RecordCount float64 `xml:"recordCount,attr"`
+}
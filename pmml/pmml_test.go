@@ -0,0 +1,131 @@
+package pmml
+
+import (
+	"bytes"
+	"context"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	files := []string{
+		"../data/contact-lenses.data.txt",
+		"../data/fishing.data.txt",
+		"../data/new-treatment.data.txt",
+	}
+	for _, filename := range files {
+		sample, err := parse.FromFile(filename)
+		if err != nil {
+			t.Fatalf("failed parsing file %s: %v", filename, err)
+		}
+		roundTrip(sample, analysis.Options{}, filename, t)
+		roundTrip(sample, analysis.Options{BinarySplits: true}, filename+" with binary splits", t)
+		for i := range sample.AttributeTypes {
+			sample.AttributeTypes[i].Ordinal = len(sample.AttributeTypes[i].Values) > 2
+		}
+		roundTrip(sample, analysis.Options{}, filename+" with ordinal attributes", t)
+	}
+}
+
+func roundTrip(sample parse.Sample, opts analysis.Options, filename string, t *testing.T) {
+	tree, err := analysis.BuildTreeContext(context.Background(), sample, opts)
+	if err != nil {
+		t.Fatalf("building tree for %s: %s", filename, err.Error())
+	}
+	var buf bytes.Buffer
+	err = Encode(&buf, Model{
+		Name:           filename,
+		Targets:        sample.Targets,
+		AttributeTypes: sample.AttributeTypes,
+		Tree:           tree,
+	})
+	if err != nil {
+		t.Fatalf("encoding %s: %s", filename, err.Error())
+	}
+	m, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding %s: %s", filename, err.Error())
+	}
+	if m.TargetName != DefaultTargetName {
+		t.Errorf("%s: expected target name %s, got %s", filename, DefaultTargetName, m.TargetName)
+	}
+	if !reflect.DeepEqual(m.Targets, sample.Targets) {
+		t.Errorf("%s: expected targets %v, got %v", filename, sample.Targets, m.Targets)
+	}
+	if !reflect.DeepEqual(m.AttributeTypes, sample.AttributeTypes) {
+		t.Errorf("%s: expected attribute types %v, got %v", filename, sample.AttributeTypes, m.AttributeTypes)
+	}
+	if !reflect.DeepEqual(m.Tree.TargetCounts, tree.TargetCounts) {
+		t.Errorf("%s: expected root counts %v, got %v", filename, tree.TargetCounts, m.Tree.TargetCounts)
+	}
+	if m.Tree.Root().CountNodes() != tree.Root().CountNodes() {
+		t.Errorf("%s: expected %d nodes, got %d", filename,
+			tree.Root().CountNodes(), m.Tree.Root().CountNodes())
+	}
+	for i, eg := range sample.Examples {
+		expected, err := tree.ClassifyExample(sample.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example %d with built tree: %s", i, err.Error())
+		}
+		got, err := m.Tree.ClassifyExample(m.AttributeTypes, eg)
+		if err != nil {
+			t.Fatalf("classifying example %d with imported tree: %s", i, err.Error())
+		}
+		if got != expected {
+			t.Errorf("%s example %d: expected %s, got %s", filename, i, expected, got)
+		}
+	}
+}
+
+func TestEncode_TargetNameCollision(t *testing.T) {
+	m := Model{
+		TargetName:     "age",
+		AttributeTypes: parse.AttributeTypes{{Name: "age"}},
+	}
+	if err := Encode(&bytes.Buffer{}, m); err == nil {
+		t.Error("expected error when the target name matches an attribute name")
+	}
+}
+
+func TestDecode_UnsupportedPredicate(t *testing.T) {
+	const doc = `<PMML version="4.4" xmlns="http://www.dmg.org/PMML-4_4">
+  <DataDictionary numberOfFields="2">
+    <DataField name="x" optype="continuous" dataType="double"/>
+    <DataField name="y" optype="categorical" dataType="string"><Value value="a"/></DataField>
+  </DataDictionary>
+  <TreeModel functionName="classification">
+    <MiningSchema><MiningField name="x"/><MiningField name="y" usageType="target"/></MiningSchema>
+    <Node score="a"><True/>
+      <Node score="a"><SimplePredicate field="x" operator="lessThan" value="1"/></Node>
+    </Node>
+  </TreeModel>
+</PMML>`
+	if _, err := Decode(strings.NewReader(doc)); err == nil {
+		t.Error("expected error for lessThan predicate")
+	}
+}
+
+func TestArray(t *testing.T) {
+	values := []string{"plain", `with "quotes"`, `back\slash`, "two words"}
+	encoded := encodeArray(values)
+	if encoded.N != 4 || encoded.Type != "string" {
+		t.Errorf("unexpected array %+v", encoded)
+	}
+	decoded, err := decodeArray(encoded.Values)
+	if err != nil {
+		t.Fatalf("decoding %s: %s", encoded.Values, err.Error())
+	}
+	if !reflect.DeepEqual(decoded, values) {
+		t.Errorf("expected %q, got %q", values, decoded)
+	}
+	decoded, err = decodeArray(" a  \"b c\"\n d ")
+	if err != nil || !reflect.DeepEqual(decoded, []string{"a", "b c", "d"}) {
+		t.Errorf("expected unquoted and quoted values, got %q (%v)", decoded, err)
+	}
+	if _, err = decodeArray(`"open`); err == nil {
+		t.Error("expected error for an unterminated quoted value")
+	}
+}
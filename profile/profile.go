@@ -0,0 +1,305 @@
+// Package profile summarizes a parse.Sample for a first look at a dataset: how its
+// examples divide among the targets, how often each attribute value occurs and with
+// which targets, the spread of real columns, and examples that repeat or contradict
+// each other.
+package profile
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"github.com/PaluMacil/decisive-oak/quality"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// DefaultBins is the number of histogram bins of a real column when Options does
+// not give one.
+const DefaultBins = 10
+
+// Options controls a profile.
+type Options struct {
+	// Bins is the number of equal-width histogram bins of each real column,
+	// DefaultBins when zero.
+	Bins int
+}
+
+// Profile summarizes a sample. Weights are the total effective weight of examples.
+type Profile struct {
+	Examples int
+	Weight   float64
+	// Targets counts the examples of each target in declared order, and is empty for
+	// a real target
+	Targets []Count `json:",omitempty"`
+	// RealTarget names a real target, which Target summarizes
+	RealTarget string   `json:",omitempty"`
+	Target     *Summary `json:",omitempty"`
+	Attributes []Attribute
+	// Duplicates and Conflicts are the groups of examples having the same values, as
+	// quality.Find reports them
+	Duplicates []quality.Group
+	Conflicts  []quality.Group
+}
+
+// Count is the weight of the examples having a value or target.
+type Count struct {
+	Value  string
+	Weight float64
+	Share  float64
+	// ByTarget is the weight of the examples having an attribute value of each
+	// target, empty for targets and for a sample with a real target
+	ByTarget map[string]float64 `json:",omitempty"`
+	// TargetMean is the mean real target of the examples having an attribute value
+	TargetMean float64 `json:",omitempty"`
+}
+
+// Attribute summarizes one attribute: the count of each declared value of a
+// nominal attribute, or the spread of a real attribute.
+type Attribute struct {
+	Name    string
+	Real    bool     `json:",omitempty"`
+	Ordinal bool     `json:",omitempty"`
+	Values  []Count  `json:",omitempty"`
+	Summary *Summary `json:",omitempty"`
+}
+
+// Summary describes the spread of a real column.
+type Summary struct {
+	Min, Max, Mean, StdDev, Median float64
+	Histogram                      []Bin
+}
+
+// Bin is the weight of the values from Low up to High, including High in the last
+// bin only.
+type Bin struct {
+	Low, High, Weight float64
+}
+
+// New profiles the sample.
+func New(sample parse.Sample, opts Options) (*Profile, error) {
+	if opts.Bins < 0 {
+		return nil, fmt.Errorf("bins %d is negative", opts.Bins)
+	}
+	if opts.Bins == 0 {
+		opts.Bins = DefaultBins
+	}
+	p := &Profile{Examples: len(sample.Examples), RealTarget: sample.RealTarget}
+	weights := make([]float64, len(sample.Examples))
+	for i, eg := range sample.Examples {
+		weights[i] = eg.EffectiveWeight()
+		p.Weight += weights[i]
+	}
+	if sample.RealTarget != "" {
+		targetValues := make([]float64, len(sample.Examples))
+		for i, eg := range sample.Examples {
+			targetValues[i] = eg.Value
+		}
+		p.Target = summarize(targetValues, weights, opts.Bins)
+	} else {
+		counts := make(map[string]float64)
+		for i, eg := range sample.Examples {
+			counts[eg.Target] += weights[i]
+		}
+		for _, t := range sample.Targets {
+			p.Targets = append(p.Targets, Count{Value: t, Weight: counts[t], Share: share(counts[t], p.Weight)})
+		}
+	}
+
+	values := make([][]string, len(sample.Examples))
+	for i, eg := range sample.Examples {
+		fields, err := sample.AttributeTypes.ExampleValues(eg)
+		if err != nil {
+			return nil, fmt.Errorf("reading example %d: %w", i, err)
+		}
+		values[i] = make([]string, len(sample.AttributeTypes))
+		for a, at := range sample.AttributeTypes {
+			values[i][a] = fields[at.Name]
+		}
+	}
+	var iReal int
+	for a, at := range sample.AttributeTypes {
+		attr := Attribute{Name: at.Name, Real: at.Real, Ordinal: at.Ordinal}
+		if at.Real {
+			column := make([]float64, len(sample.Examples))
+			for i, eg := range sample.Examples {
+				column[i] = eg.RealValues[iReal]
+			}
+			iReal++
+			attr.Summary = summarize(column, weights, opts.Bins)
+			p.Attributes = append(p.Attributes, attr)
+			continue
+		}
+		counts := make(map[string]float64)
+		targetSums := make(map[string]float64)
+		for i, eg := range sample.Examples {
+			counts[values[i][a]] += weights[i]
+			targetSums[values[i][a]] += weights[i] * eg.Value
+		}
+		var byTarget parse.AttributeOccurrenceLookup
+		if sample.RealTarget == "" {
+			var err error
+			if byTarget, err = at.OccurrencesInTargets(sample); err != nil {
+				return nil, err
+			}
+		}
+		for _, v := range at.Values {
+			c := Count{Value: v, Weight: counts[v], Share: share(counts[v], p.Weight)}
+			if byTarget != nil {
+				c.ByTarget = make(map[string]float64)
+				for t, weight := range byTarget[v] {
+					c.ByTarget[sample.Targets[t]] = weight
+				}
+			} else {
+				c.TargetMean = share(targetSums[v], counts[v])
+			}
+			attr.Values = append(attr.Values, c)
+		}
+		p.Attributes = append(p.Attributes, attr)
+	}
+	report, err := quality.Find(sample)
+	if err != nil {
+		return nil, err
+	}
+	p.Duplicates, p.Conflicts = report.Duplicates, report.Conflicts
+
+	return p, nil
+}
+
+func share(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return part / whole
+}
+
+// summarize returns the weighted spread of the values with an equal-width histogram.
+func summarize(values, weights []float64, bins int) *Summary {
+	if len(values) == 0 {
+		return &Summary{}
+	}
+	s := &Summary{Min: math.Inf(1), Max: math.Inf(-1)}
+	var total float64
+	for i, v := range values {
+		s.Min, s.Max = math.Min(s.Min, v), math.Max(s.Max, v)
+		s.Mean += weights[i] * v
+		total += weights[i]
+	}
+	s.Mean /= total
+	for i, v := range values {
+		s.StdDev += weights[i] * (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(s.StdDev / total)
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+	// the weighted median is the first value at which half the weight is reached
+	var cumulative float64
+	for _, i := range order {
+		cumulative += weights[i]
+		if cumulative >= total/2 {
+			s.Median = values[i]
+			break
+		}
+	}
+
+	width := (s.Max - s.Min) / float64(bins)
+	if width == 0 {
+		// a constant column fills a single bin
+		s.Histogram = []Bin{{Low: s.Min, High: s.Max, Weight: total}}
+		return s
+	}
+	s.Histogram = make([]Bin, bins)
+	for b := range s.Histogram {
+		s.Histogram[b].Low = s.Min + float64(b)*width
+		s.Histogram[b].High = s.Min + float64(b+1)*width
+	}
+	s.Histogram[bins-1].High = s.Max
+	for i, v := range values {
+		b := int((v - s.Min) / width)
+		if b >= bins {
+			b = bins - 1
+		}
+		s.Histogram[b].Weight += weights[i]
+	}
+
+	return s
+}
+
+// String renders the profile as text: the targets, each attribute's values or
+// spread, and the duplicate and conflicting examples.
+func (p *Profile) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d examples of weight %g\n\n", p.Examples, p.Weight)
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if p.Target != nil {
+		fmt.Fprintf(tw, "target %s\t\n", p.RealTarget)
+		p.Target.write(tw)
+	} else {
+		fmt.Fprint(tw, "target\tweight\tshare\t\n")
+		for _, c := range p.Targets {
+			fmt.Fprintf(tw, "%s\t%g\t%.3f\t\n", c.Value, c.Weight, c.Share)
+		}
+	}
+	for _, a := range p.Attributes {
+		fmt.Fprintln(tw)
+		if a.Summary != nil {
+			fmt.Fprintf(tw, "%s (real)\t\n", a.Name)
+			a.Summary.write(tw)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\tweight\tshare\t", a.Name)
+		for _, t := range p.Targets {
+			fmt.Fprintf(tw, "%s\t", t.Value)
+		}
+		if p.Target != nil {
+			fmt.Fprint(tw, "target mean\t")
+		}
+		fmt.Fprintln(tw)
+		for _, c := range a.Values {
+			fmt.Fprintf(tw, "%s\t%g\t%.3f\t", c.Value, c.Weight, c.Share)
+			for _, t := range p.Targets {
+				fmt.Fprintf(tw, "%g\t", c.ByTarget[t.Value])
+			}
+			if p.Target != nil {
+				fmt.Fprintf(tw, "%.4g\t", c.TargetMean)
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+	tw.Flush()
+	writeGroups(&sb, "duplicate", p.Duplicates)
+	writeGroups(&sb, "conflicting", p.Conflicts)
+
+	return sb.String()
+}
+
+func (s *Summary) write(tw *tabwriter.Writer) {
+	fmt.Fprintf(tw, "min\t%.4g\t\nmax\t%.4g\t\nmean\t%.4g\t\nstd dev\t%.4g\t\nmedian\t%.4g\t\n",
+		s.Min, s.Max, s.Mean, s.StdDev, s.Median)
+	var most float64
+	for _, b := range s.Histogram {
+		most = math.Max(most, b.Weight)
+	}
+	for _, b := range s.Histogram {
+		bar := strings.Repeat("#", int(math.Round(share(b.Weight, most)*20)))
+		fmt.Fprintf(tw, "%.4g to %.4g\t%g\t %s\n", b.Low, b.High, b.Weight, bar)
+	}
+}
+
+func writeGroups(sb *strings.Builder, kind string, groups []quality.Group) {
+	fmt.Fprintf(sb, "\n%s examples: %d groups\n", kind, len(groups))
+	for _, g := range groups {
+		targets := make([]string, 0, len(g.Targets))
+		for t, weight := range g.Targets {
+			targets = append(targets, fmt.Sprintf("%s: %g", t, weight))
+		}
+		sort.Strings(targets)
+		fmt.Fprintf(sb, "%s: %s (%s)\n", strings.Join(g.Values, ", "),
+			g.Locations(), strings.Join(targets, ", "))
+	}
+}
@@ -0,0 +1,92 @@
+package profile
+
+import (
+	"encoding/json"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const data = `2
+low,high
+3
+colour,2,red,blue
+weight,real
+size,2,s,l
+6
+red,1,s,low
+red,1,s,low
+blue,2,l,high
+blue,2,l,low
+red,3,s,high
+blue,5,l,high
+`
+
+func TestNew(t *testing.T) {
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	p, err := New(sample, Options{Bins: 4})
+	if err != nil {
+		t.Fatalf("profiling: %v", err)
+	}
+	if p.Examples != 6 || p.Targets[0].Weight != 3 || p.Targets[1].Share != 0.5 {
+		t.Errorf("expected 3 low and 3 high examples, got %v", p.Targets)
+	}
+	colour := p.Attributes[0]
+	if colour.Values[0].Weight != 3 || !reflect.DeepEqual(colour.Values[0].ByTarget, map[string]float64{"low": 2, "high": 1}) {
+		t.Errorf("expected 3 red examples, 2 low and 1 high, got %+v", colour.Values[0])
+	}
+	// size comes after a real attribute, whose values are held apart from nominal ones
+	if size := p.Attributes[2]; size.Values[1].ByTarget["high"] != 2 {
+		t.Errorf("expected 2 high examples of size l, got %+v", size.Values[1])
+	}
+	weight := p.Attributes[1].Summary
+	if weight == nil || weight.Min != 1 || weight.Max != 5 || weight.Median != 2 || weight.Mean != 14.0/6 {
+		t.Fatalf("expected weights from 1 to 5 with median 2 and mean 14/6, got %+v", weight)
+	}
+	var counts []float64
+	for _, b := range weight.Histogram {
+		counts = append(counts, b.Weight)
+	}
+	if !reflect.DeepEqual(counts, []float64{2, 2, 1, 1}) {
+		t.Errorf("expected histogram counts [2 2 1 1], got %v", counts)
+	}
+	if len(p.Duplicates) != 1 || !reflect.DeepEqual(p.Duplicates[0].Examples, []int{0, 1}) {
+		t.Errorf("expected the first two examples to be duplicates, got %+v", p.Duplicates)
+	}
+	if len(p.Conflicts) != 1 || !reflect.DeepEqual(p.Conflicts[0].Examples, []int{2, 3}) {
+		t.Errorf("expected the third and fourth examples to conflict, got %+v", p.Conflicts)
+	}
+	if _, err = json.Marshal(p); err != nil {
+		t.Errorf("encoding profile: %v", err)
+	}
+	if !strings.Contains(p.String(), "conflicting examples: 1 groups") {
+		t.Errorf("expected the text to count the conflicts, got\n%s", p.String())
+	}
+}
+
+func TestNew_RealTarget(t *testing.T) {
+	schema, err := parse.SchemaFromFile("../data/fishing-catch.schema.json")
+	if err != nil {
+		t.Fatalf("failed reading schema: %v", err)
+	}
+	sample, err := parse.FromFileWithSchema("../data/fishing-catch.csv", schema, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed parsing file fishing-catch.csv: %v", err)
+	}
+	p, err := New(sample, Options{})
+	if err != nil {
+		t.Fatalf("profiling: %v", err)
+	}
+	if p.Target == nil || len(p.Target.Histogram) != DefaultBins || len(p.Targets) != 0 {
+		t.Errorf("expected the real target to be summarized in %d bins, got %+v", DefaultBins, p.Target)
+	}
+	for _, v := range p.Attributes[0].Values {
+		if v.Weight > 0 && v.TargetMean == 0 {
+			t.Errorf("expected a mean catch for %s, got %+v", v.Value, v)
+		}
+	}
+}
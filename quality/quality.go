@@ -0,0 +1,268 @@
+// Package quality finds examples of a parse.Sample that repeat or contradict each
+// other and cleans them out. ID3 cannot split examples that have the same attribute
+// values, so when their targets differ it stops with a leaf of the most common
+// target without saying so; finding them first shows where the data disagrees.
+package quality
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Action is a way of cleaning repeated or conflicting examples.
+type Action string
+
+const (
+	// Dedupe keeps the first of the examples having the same values and target.
+	Dedupe Action = "dedupe"
+	// Resolve keeps one example of each conflicting group, the first having the
+	// target of the greatest weight in the group, taking the earliest declared
+	// target, or the earliest example's real target, on a tie.
+	Resolve Action = "resolve"
+	// DropConflicts removes every example of each conflicting group.
+	DropConflicts Action = "drop-conflicts"
+)
+
+// Group is a set of examples having the same attribute values.
+type Group struct {
+	// Values are the examples' attribute values in declared order
+	Values []string
+	// Examples are the indexes of the examples in the sample
+	Examples []int
+	// Lines are the examples' lines in the data file they were read from, empty when
+	// the examples were not parsed
+	Lines []int `json:",omitempty"`
+	// Targets is the weight of the examples of each target, or of each formatted
+	// value of a real target
+	Targets map[string]float64
+}
+
+// Report lists the repeated and conflicting examples of a sample.
+type Report struct {
+	Examples int
+	// Duplicates are the groups of examples having the same values and target
+	Duplicates []Group
+	// Conflicts are the groups of examples having the same values but different
+	// targets, which no tree can tell apart
+	Conflicts []Group
+}
+
+// Find groups the examples of the sample having the same attribute values, in the
+// order of each group's first example.
+func Find(sample parse.Sample) (*Report, error) {
+	r := &Report{Examples: len(sample.Examples)}
+	byKey := make(map[string]int)
+	var all []Group
+	for i, eg := range sample.Examples {
+		fields, err := sample.AttributeTypes.ExampleValues(eg)
+		if err != nil {
+			return nil, fmt.Errorf("reading example %d: %w", i, err)
+		}
+		values := make([]string, len(sample.AttributeTypes))
+		for a, at := range sample.AttributeTypes {
+			values[a] = fields[at.Name]
+		}
+		key := strings.Join(values, "\x00")
+		g, ok := byKey[key]
+		if !ok {
+			g = len(all)
+			byKey[key] = g
+			all = append(all, Group{Values: values, Targets: make(map[string]float64)})
+		}
+		all[g].Examples = append(all[g].Examples, i)
+		if eg.Line > 0 {
+			all[g].Lines = append(all[g].Lines, eg.Line)
+		}
+		all[g].Targets[target(sample, eg)] += eg.EffectiveWeight()
+	}
+	for _, g := range all {
+		// lines are only reported when every example of the group has one
+		if len(g.Lines) != len(g.Examples) {
+			g.Lines = nil
+		}
+		switch {
+		case len(g.Examples) < 2:
+		case len(g.Targets) == 1:
+			r.Duplicates = append(r.Duplicates, g)
+		default:
+			r.Conflicts = append(r.Conflicts, g)
+		}
+	}
+
+	return r, nil
+}
+
+// target returns the example's target, formatting a real target.
+func target(sample parse.Sample, eg parse.Example) string {
+	if sample.RealTarget != "" {
+		return strconv.FormatFloat(eg.Value, 'g', -1, 64)
+	}
+
+	return eg.Target
+}
+
+// Redundant returns the number of examples that Dedupe would remove: all but the
+// first of each target in every group.
+func (r *Report) Redundant() int {
+	var redundant int
+	for _, g := range r.Duplicates {
+		redundant += len(g.Examples) - 1
+	}
+	for _, g := range r.Conflicts {
+		redundant += len(g.Examples) - len(g.Targets)
+	}
+
+	return redundant
+}
+
+// Clean returns a copy of the sample without the examples the actions remove, in
+// their original order, along with the indexes of the removed examples. Resolve and
+// DropConflicts cannot be combined, and Dedupe leaves one example of each target in
+// a conflicting group that neither of them handles. The weight of removed examples
+// is discarded rather than added to those that remain.
+func (r *Report) Clean(sample parse.Sample, actions ...Action) (parse.Sample, []int, error) {
+	if len(sample.Examples) != r.Examples {
+		return parse.Sample{}, nil, fmt.Errorf("report is of %d examples, sample has %d", r.Examples, len(sample.Examples))
+	}
+	var dedupe, resolve, drop bool
+	for _, a := range actions {
+		switch a {
+		case Dedupe:
+			dedupe = true
+		case Resolve:
+			resolve = true
+		case DropConflicts:
+			drop = true
+		default:
+			return parse.Sample{}, nil, fmt.Errorf("unknown action %q: expected %s, %s or %s", a, Dedupe, Resolve, DropConflicts)
+		}
+	}
+	if resolve && drop {
+		return parse.Sample{}, nil, fmt.Errorf("%s and %s cannot be combined", Resolve, DropConflicts)
+	}
+
+	remove := make(map[int]bool)
+	for _, g := range r.Conflicts {
+		switch {
+		case drop:
+			for _, i := range g.Examples {
+				remove[i] = true
+			}
+		case resolve:
+			keep := g.majority(sample)
+			for _, i := range g.Examples {
+				remove[i] = i != keep
+			}
+		case dedupe:
+			dedupeGroup(sample, g, remove)
+		}
+	}
+	if dedupe {
+		for _, g := range r.Duplicates {
+			dedupeGroup(sample, g, remove)
+		}
+	}
+
+	cleaned := sample
+	cleaned.Examples = make(parse.Examples, 0, len(sample.Examples))
+	var removed []int
+	for i, eg := range sample.Examples {
+		if remove[i] {
+			removed = append(removed, i)
+			continue
+		}
+		cleaned.Examples = append(cleaned.Examples, eg)
+	}
+	cleaned.NumExamples = len(cleaned.Examples)
+
+	return cleaned, removed, nil
+}
+
+// dedupeGroup marks all but the first example of each target in the group for removal.
+func dedupeGroup(sample parse.Sample, g Group, remove map[int]bool) {
+	seen := make(map[string]bool)
+	for _, i := range g.Examples {
+		t := target(sample, sample.Examples[i])
+		remove[i] = seen[t]
+		seen[t] = true
+	}
+}
+
+// majority returns the index of the first example having the group's target of the
+// greatest weight.
+func (g Group) majority(sample parse.Sample) int {
+	// candidates are in declared target order, or in order of appearance for a real target
+	var candidates []string
+	if sample.RealTarget == "" {
+		candidates = sample.Targets
+	} else {
+		for _, i := range g.Examples {
+			candidates = append(candidates, target(sample, sample.Examples[i]))
+		}
+	}
+	var best string
+	for _, t := range candidates {
+		if weight, ok := g.Targets[t]; ok && (best == "" || weight > g.Targets[best]) {
+			best = t
+		}
+	}
+	for _, i := range g.Examples {
+		if target(sample, sample.Examples[i]) == best {
+			return i
+		}
+	}
+
+	return g.Examples[0]
+}
+
+// String lists each group by line number, or by example index when the examples
+// were not read from a file.
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "examples: %d, duplicate groups: %d, redundant examples: %d, conflicting groups: %d\n",
+		r.Examples, len(r.Duplicates), r.Redundant(), len(r.Conflicts))
+	writeGroups(&sb, "duplicate", r.Duplicates)
+	writeGroups(&sb, "conflicting", r.Conflicts)
+
+	return sb.String()
+}
+
+func writeGroups(sb *strings.Builder, kind string, groups []Group) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\n%s examples:\n", kind)
+	for _, g := range groups {
+		fmt.Fprintf(sb, "%s: %s (%s)\n", strings.Join(g.Values, ", "), g.Locations(), g.targetWeights())
+	}
+}
+
+// Locations describes where the group's examples are, by line when known.
+func (g Group) Locations() string {
+	kind, positions := "examples", g.Examples
+	if len(g.Lines) > 0 {
+		kind, positions = "lines", g.Lines
+	}
+	formatted := make([]string, len(positions))
+	for i, p := range positions {
+		formatted[i] = strconv.Itoa(p)
+	}
+
+	return kind + " " + strings.Join(formatted, ", ")
+}
+
+func (g Group) targetWeights() string {
+	targets := make([]string, 0, len(g.Targets))
+	for t := range g.Targets {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	for i, t := range targets {
+		targets[i] = fmt.Sprintf("%s: %g", t, g.Targets[t])
+	}
+
+	return strings.Join(targets, ", ")
+}
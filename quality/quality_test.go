@@ -0,0 +1,177 @@
+package quality
+
+import (
+	"bytes"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the blank line shifts the lines of the examples after it
+const data = `2
+yes,no
+3
+outlook,2,sunny,rain
+importance,weight
+windy,2,true,false
+8
+sunny,1,true,yes
+sunny,1,true,yes
+
+rain,1,false,no
+rain,3,false,yes
+rain,1,false,no
+sunny,1,false,no
+rain,1,true,yes
+rain,1,true,no
+`
+
+func TestFind(t *testing.T) {
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	r, err := Find(sample)
+	if err != nil {
+		t.Fatalf("finding groups: %v", err)
+	}
+	expectedDuplicates := []Group{{
+		Values:   []string{"sunny", "true"},
+		Examples: []int{0, 1},
+		Lines:    []int{8, 9},
+		Targets:  map[string]float64{"yes": 2},
+	}}
+	if !reflect.DeepEqual(r.Duplicates, expectedDuplicates) {
+		t.Errorf("expected duplicates %+v, got %+v", expectedDuplicates, r.Duplicates)
+	}
+	expectedConflicts := []Group{{
+		Values:   []string{"rain", "false"},
+		Examples: []int{2, 3, 4},
+		Lines:    []int{11, 12, 13},
+		Targets:  map[string]float64{"no": 2, "yes": 3},
+	}, {
+		Values:   []string{"rain", "true"},
+		Examples: []int{6, 7},
+		Lines:    []int{15, 16},
+		Targets:  map[string]float64{"yes": 1, "no": 1},
+	}}
+	if !reflect.DeepEqual(r.Conflicts, expectedConflicts) {
+		t.Errorf("expected conflicts %+v, got %+v", expectedConflicts, r.Conflicts)
+	}
+	if r.Redundant() != 2 {
+		t.Errorf("expected 2 redundant examples, got %d", r.Redundant())
+	}
+	text := r.String()
+	for _, want := range []string{"conflicting groups: 2", "sunny, true: lines 8, 9 (yes: 2)", "rain, false: lines 11, 12, 13 (no: 2, yes: 3)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected report to contain %q, got\n%s", want, text)
+		}
+	}
+}
+
+func TestReport_Clean(t *testing.T) {
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	r, err := Find(sample)
+	if err != nil {
+		t.Fatalf("finding groups: %v", err)
+	}
+	tests := []struct {
+		actions []Action
+		removed []int
+	}{
+		{nil, nil},
+		{[]Action{Dedupe}, []int{1, 4}},
+		// the weighted majority of the first conflict is yes, and the second is a tie
+		// settled by the declared order of the targets
+		{[]Action{Resolve}, []int{2, 4, 7}},
+		{[]Action{Dedupe, Resolve}, []int{1, 2, 4, 7}},
+		{[]Action{DropConflicts}, []int{2, 3, 4, 6, 7}},
+		{[]Action{Dedupe, DropConflicts}, []int{1, 2, 3, 4, 6, 7}},
+	}
+	for _, tt := range tests {
+		cleaned, removed, err := r.Clean(sample, tt.actions...)
+		if err != nil {
+			t.Errorf("%v: %v", tt.actions, err)
+			continue
+		}
+		if !reflect.DeepEqual(removed, tt.removed) {
+			t.Errorf("%v: expected to remove %v, got %v", tt.actions, tt.removed, removed)
+		}
+		if cleaned.NumExamples != len(sample.Examples)-len(tt.removed) || len(cleaned.Examples) != cleaned.NumExamples {
+			t.Errorf("%v: expected %d examples, got %d", tt.actions, len(sample.Examples)-len(tt.removed), cleaned.NumExamples)
+		}
+		var buf bytes.Buffer
+		if err := parse.Write(&buf, cleaned); err != nil {
+			t.Fatalf("%v: writing cleaned sample: %v", tt.actions, err)
+		}
+		if _, err := parse.Parse(&buf); err != nil {
+			t.Errorf("%v: parsing cleaned sample: %v", tt.actions, err)
+		}
+	}
+	if len(sample.Examples) != 8 {
+		t.Errorf("expected cleaning to leave the sample alone, got %d examples", len(sample.Examples))
+	}
+
+	if _, _, err := r.Clean(sample, Resolve, DropConflicts); err == nil {
+		t.Error("expected an error combining resolve and drop-conflicts")
+	}
+	if _, _, err := r.Clean(sample, "merge"); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+	sample.Examples = sample.Examples[:4]
+	if _, _, err := r.Clean(sample, Dedupe); err == nil {
+		t.Error("expected an error cleaning a different sample than was reported")
+	}
+}
+
+func TestReport_Clean_RealTarget(t *testing.T) {
+	sample, err := parse.Parse(strings.NewReader(`1
+price,real
+1
+size,2,s,l
+4
+s,2
+l,1.5
+s,3
+l,1.5
+`))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	r, err := Find(sample)
+	if err != nil {
+		t.Fatalf("finding groups: %v", err)
+	}
+	if len(r.Duplicates) != 1 || len(r.Conflicts) != 1 {
+		t.Fatalf("expected the l examples to repeat and the s examples to conflict, got %+v and %+v", r.Duplicates, r.Conflicts)
+	}
+	// with a tie, the first example's value is kept
+	cleaned, removed, err := r.Clean(sample, Dedupe, Resolve)
+	if err != nil {
+		t.Fatalf("cleaning: %v", err)
+	}
+	if !reflect.DeepEqual(removed, []int{2, 3}) || cleaned.Examples[0].Value != 2 {
+		t.Errorf("expected to keep the first s and l examples, removed %v leaving %+v", removed, cleaned.Examples)
+	}
+}
+
+func TestFind_Unparsed(t *testing.T) {
+	sample, err := parse.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing sample: %v", err)
+	}
+	for i := range sample.Examples {
+		sample.Examples[i].Line = 0
+	}
+	r, err := Find(sample)
+	if err != nil {
+		t.Fatalf("finding groups: %v", err)
+	}
+	if r.Duplicates[0].Lines != nil || r.Duplicates[0].Locations() != "examples 0, 1" {
+		t.Errorf("expected examples without lines to be located by index, got %q", r.Duplicates[0].Locations())
+	}
+}
@@ -0,0 +1,198 @@
+// Package resample rebalances the targets of a parse.Sample by repeating, removing
+// or synthesizing examples, for use on training data before building a tree.
+package resample
+
+import (
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// byTarget groups the indexes of the sample's examples by target, in declared target order
+func byTarget(sample parse.Sample) [][]int {
+	groups := make([][]int, len(sample.Targets))
+	for i, eg := range sample.Examples {
+		if t, err := sample.Targets.Index(eg.Target); err == nil {
+			groups[t] = append(groups[t], i)
+		}
+	}
+
+	return groups
+}
+
+func withExamples(sample parse.Sample, examples parse.Examples) parse.Sample {
+	sample.Examples = examples
+	sample.NumExamples = len(examples)
+
+	return sample
+}
+
+// Oversample adds randomly repeated examples of every target until each present
+// target has as many examples as the most common one.
+func Oversample(sample parse.Sample, rng *rand.Rand) parse.Sample {
+	groups := byTarget(sample)
+	var most int
+	for _, g := range groups {
+		if len(g) > most {
+			most = len(g)
+		}
+	}
+	examples := append(parse.Examples{}, sample.Examples...)
+	for _, g := range groups {
+		for n := len(g); n > 0 && n < most; n++ {
+			examples = append(examples, sample.Examples[g[rng.Intn(len(g))]])
+		}
+	}
+
+	return withExamples(sample, examples)
+}
+
+// Undersample keeps a random selection of the examples of every target, as many
+// as the rarest present target has, in their original order.
+func Undersample(sample parse.Sample, rng *rand.Rand) parse.Sample {
+	groups := byTarget(sample)
+	fewest := -1
+	for _, g := range groups {
+		if len(g) > 0 && (fewest < 0 || len(g) < fewest) {
+			fewest = len(g)
+		}
+	}
+	keep := make([]bool, len(sample.Examples))
+	for _, g := range groups {
+		chosen := append([]int{}, g...)
+		rng.Shuffle(len(chosen), func(i, j int) { chosen[i], chosen[j] = chosen[j], chosen[i] })
+		for i := 0; i < fewest && i < len(chosen); i++ {
+			keep[chosen[i]] = true
+		}
+	}
+	var examples parse.Examples
+	for i, eg := range sample.Examples {
+		if keep[i] {
+			examples = append(examples, eg)
+		}
+	}
+
+	return withExamples(sample, examples)
+}
+
+// SMOTE adds synthetic examples of every target until each present target has as
+// many examples as the most common one. Each synthetic example is made from a
+// random example of the target and one of its k nearest neighbours of the same
+// target, taking each nominal value from either of the two at random and placing
+// each real value at a random point between them. Distance counts one for each
+// differing nominal value plus the difference between real values as a fraction of
+// the attribute's range. A target with a single example is oversampled by repetition.
+func SMOTE(sample parse.Sample, k int, rng *rand.Rand) (parse.Sample, error) {
+	if k < 1 {
+		return sample, fmt.Errorf("SMOTE needs at least one neighbour, got k=%d", k)
+	}
+	ranges := realRanges(sample)
+	groups := byTarget(sample)
+	var most int
+	for _, g := range groups {
+		if len(g) > most {
+			most = len(g)
+		}
+	}
+	examples := append(parse.Examples{}, sample.Examples...)
+	for _, g := range groups {
+		if len(g) == 0 || len(g) == most {
+			continue
+		}
+		neighbours := nearestNeighbours(sample, g, k, ranges)
+		for n := len(g); n < most; n++ {
+			i := rng.Intn(len(g))
+			base := sample.Examples[g[i]]
+			if len(neighbours[i]) == 0 {
+				examples = append(examples, base)
+				continue
+			}
+			other := sample.Examples[neighbours[i][rng.Intn(len(neighbours[i]))]]
+			examples = append(examples, synthesize(base, other, rng))
+		}
+	}
+
+	return withExamples(sample, examples), nil
+}
+
+func synthesize(base, other parse.Example, rng *rand.Rand) parse.Example {
+	eg := parse.Example{Target: base.Target, Weight: base.Weight}
+	eg.StringValues = make([]string, len(base.StringValues))
+	for i := range base.StringValues {
+		eg.StringValues[i] = base.StringValues[i]
+		if rng.Intn(2) == 1 {
+			eg.StringValues[i] = other.StringValues[i]
+		}
+	}
+	if len(base.RealValues) > 0 {
+		eg.RealValues = make([]float64, len(base.RealValues))
+		for i := range base.RealValues {
+			eg.RealValues[i] = base.RealValues[i] + rng.Float64()*(other.RealValues[i]-base.RealValues[i])
+		}
+	}
+
+	return eg
+}
+
+// realRanges returns the spread of each real attribute, in RealValues order
+func realRanges(sample parse.Sample) []float64 {
+	var ranges []float64
+	var iReal int
+	for _, at := range sample.AttributeTypes {
+		if !at.Real {
+			continue
+		}
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, eg := range sample.Examples {
+			min = math.Min(min, eg.RealValues[iReal])
+			max = math.Max(max, eg.RealValues[iReal])
+		}
+		ranges = append(ranges, max-min)
+		iReal++
+	}
+
+	return ranges
+}
+
+func distance(a, b parse.Example, ranges []float64) float64 {
+	var d float64
+	for i := range a.StringValues {
+		if a.StringValues[i] != b.StringValues[i] {
+			d++
+		}
+	}
+	for i, r := range ranges {
+		if r > 0 {
+			d += math.Abs(a.RealValues[i]-b.RealValues[i]) / r
+		}
+	}
+
+	return d
+}
+
+// nearestNeighbours returns, for each example in the group, the indexes of the k
+// other examples of the group closest to it.
+func nearestNeighbours(sample parse.Sample, group []int, k int, ranges []float64) [][]int {
+	neighbours := make([][]int, len(group))
+	for i, a := range group {
+		others := make([]int, 0, len(group)-1)
+		for _, b := range group {
+			if b != a {
+				others = append(others, b)
+			}
+		}
+		distances := make(map[int]float64, len(others))
+		for _, b := range others {
+			distances[b] = distance(sample.Examples[a], sample.Examples[b], ranges)
+		}
+		sort.SliceStable(others, func(x, y int) bool { return distances[others[x]] < distances[others[y]] })
+		if len(others) > k {
+			others = others[:k]
+		}
+		neighbours[i] = others
+	}
+
+	return neighbours
+}
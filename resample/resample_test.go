@@ -0,0 +1,162 @@
+package resample
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/evaluate"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// imbalancedSample generates about 5% "rare" examples, most of which have the
+// first two attributes at their first value, where they are still outnumbered.
+func imbalancedSample(rows int, rng *rand.Rand) parse.Sample {
+	sample := parse.Sample{
+		NumTargets:    2,
+		Targets:       parse.Targets{"common", "rare"},
+		NumAttributes: 4,
+	}
+	for i := 0; i < 4; i++ {
+		sample.AttributeTypes = append(sample.AttributeTypes, parse.AttributeType{
+			Name:      fmt.Sprintf("a%d", i),
+			NumValues: 3,
+			Values:    []string{"x", "y", "z"},
+		})
+	}
+	for r := 0; r < rows; r++ {
+		codes := make([]int, 4)
+		values := make([]string, 4)
+		for i := range codes {
+			codes[i] = rng.Intn(3)
+			values[i] = sample.AttributeTypes[i].Values[codes[i]]
+		}
+		pRare := 0.01
+		if codes[0] == 0 && codes[1] == 0 {
+			pRare = 0.4
+		}
+		target := "common"
+		if rng.Float64() < pRare {
+			target = "rare"
+		}
+		sample.Examples = append(sample.Examples, parse.Example{StringValues: values, Target: target})
+	}
+	sample.NumExamples = rows
+
+	return sample
+}
+
+func countTargets(sample parse.Sample) map[string]int {
+	counts := make(map[string]int)
+	for _, eg := range sample.Examples {
+		counts[eg.Target]++
+	}
+
+	return counts
+}
+
+func TestResample_Balances(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sample := imbalancedSample(2000, rng)
+	original := countTargets(sample)
+	over := countTargets(Oversample(sample, rng))
+	if over["rare"] != original["common"] || over["common"] != original["common"] {
+		t.Errorf("oversampling: expected %d of each, got %v", original["common"], over)
+	}
+	under := countTargets(Undersample(sample, rng))
+	if under["rare"] != original["rare"] || under["common"] != original["rare"] {
+		t.Errorf("undersampling: expected %d of each, got %v", original["rare"], under)
+	}
+	smoted, err := SMOTE(sample, 5, rng)
+	if err != nil {
+		t.Fatalf("SMOTE: %s", err.Error())
+	}
+	if counts := countTargets(smoted); counts["rare"] != original["common"] {
+		t.Errorf("SMOTE: expected %d rare examples, got %v", original["common"], counts)
+	}
+	// synthetic examples must still be valid for the attribute types
+	if err = parse.Write(ioutil.Discard, smoted); err != nil {
+		t.Fatalf("writing SMOTE sample: %s", err.Error())
+	}
+	for _, eg := range smoted.Examples[len(sample.Examples):] {
+		if eg.Target != "rare" {
+			t.Fatalf("expected only rare examples to be synthesized, got %s", eg.Target)
+		}
+		for i, v := range eg.StringValues {
+			if !sample.AttributeTypes[i].IsValidValue(v) {
+				t.Fatalf("synthesized invalid value %s", v)
+			}
+		}
+	}
+	if _, err = SMOTE(sample, 0, rng); err == nil {
+		t.Error("expected error for k=0")
+	}
+}
+
+func TestSMOTE_Real(t *testing.T) {
+	sample := parse.Sample{
+		Targets:        parse.Targets{"a", "b"},
+		AttributeTypes: parse.AttributeTypes{{Name: "r", Real: true}},
+		Examples: parse.Examples{
+			{RealValues: []float64{0}, Target: "a"},
+			{RealValues: []float64{1}, Target: "a"},
+			{RealValues: []float64{2}, Target: "a"},
+			{RealValues: []float64{10}, Target: "b"},
+			{RealValues: []float64{20}, Target: "b"},
+		},
+	}
+	smoted, err := SMOTE(sample, 1, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("SMOTE: %s", err.Error())
+	}
+	synthetic := smoted.Examples[len(sample.Examples)]
+	if synthetic.Target != "b" || synthetic.RealValues[0] < 10 || synthetic.RealValues[0] > 20 {
+		t.Errorf("expected a b example between 10 and 20, got %+v", synthetic)
+	}
+}
+
+// TestRareRecall compares the recall of the rare target on held out data when the
+// training data is used as is, with balanced class weights and after resampling.
+func TestRareRecall(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	rng := rand.New(rand.NewSource(7))
+	train, test, err := evaluate.Holdout(imbalancedSample(6000, rng), 0.3, rng)
+	if err != nil {
+		t.Fatalf("holding out: %v", err)
+	}
+
+	recall := func(train parse.Sample, opts analysis.Options) float64 {
+		tree, err := analysis.BuildTreeContext(context.Background(), train, opts)
+		if err != nil {
+			t.Fatalf("building tree: %s", err.Error())
+		}
+		confusion, err := evaluate.Tree(tree, test)
+		if err != nil {
+			t.Fatalf("evaluating tree: %s", err.Error())
+		}
+		return confusion.Recall("rare")
+	}
+	smoted, err := SMOTE(train, 5, rng)
+	if err != nil {
+		t.Fatalf("SMOTE: %s", err.Error())
+	}
+	plain := recall(train, analysis.Options{})
+	improved := map[string]float64{
+		"balanced class weights": recall(train, analysis.Options{BalancedClassWeights: true}),
+		"manual class weights":   recall(train, analysis.Options{ClassWeights: map[string]float64{"rare": 10}}),
+		"oversampling":           recall(Oversample(train, rng), analysis.Options{}),
+		"undersampling":          recall(Undersample(train, rng), analysis.Options{}),
+		"SMOTE":                  recall(smoted, analysis.Options{}),
+	}
+	t.Logf("rare recall without rebalancing: %.3f", plain)
+	for name, r := range improved {
+		t.Logf("rare recall with %s: %.3f", name, r)
+		if r < plain+0.3 {
+			t.Errorf("expected %s to improve rare recall of %.3f by at least 0.3, got %.3f", name, plain, r)
+		}
+	}
+}
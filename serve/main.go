@@ -3,10 +3,15 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 func main() {
@@ -19,7 +24,7 @@ func main() {
 	fsTree := http.FileServer(http.Dir("./out"))
 	http.HandleFunc("/api/list/files", func(w http.ResponseWriter, r *http.Request) {
 		var treeItems []TreeItem
-		files, _ := filepath.Glob("out/*.data.tree.json")
+		files, _ := filepath.Glob("out/*.tree.json")
 		for _, filename := range files {
 			item := TreeItem{
 				Filename: "tree/" + filepath.Base(filename),
@@ -28,6 +33,83 @@ func main() {
 		}
 		json.NewEncoder(w).Encode(treeItems)
 	})
+	http.HandleFunc("/api/list/profiles", func(w http.ResponseWriter, r *http.Request) {
+		var profileItems []ProfileItem
+		files, _ := filepath.Glob("out/*.profile.json")
+		for _, filename := range files {
+			profileItems = append(profileItems, ProfileItem{
+				Filename: "tree/" + filepath.Base(filename),
+			})
+		}
+		json.NewEncoder(w).Encode(profileItems)
+	})
+	// importance serves the importance written alongside a tree, or the gain of each
+	// attribute's splits when there is none or the tree was rebuilt since
+	http.HandleFunc("/api/importance/", func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		if !strings.HasSuffix(name, ".tree.json") {
+			http.NotFound(w, r)
+			return
+		}
+		treeFilename := filepath.Join("out", name)
+		treeInfo, err := os.Stat(treeFilename)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		importanceFilename := filepath.Join("out", strings.TrimSuffix(name, ".tree.json")+".importance.json")
+		if info, err := os.Stat(importanceFilename); err == nil && !info.ModTime().Before(treeInfo.ModTime()) {
+			http.ServeFile(w, r, importanceFilename)
+			return
+		}
+		treeData, err := ioutil.ReadFile(treeFilename)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var tree analysis.Node
+		if err = json.Unmarshal(treeData, &tree); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(tree.Importance())
+	})
+	// relevance measures the attributes of a sample the command line wrote to the out
+	// folder, such as contact-lenses.data.json, flagging them at the thresholds given
+	// by the near-constant and id-like query parameters
+	http.HandleFunc("/api/relevance/", func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		if !strings.HasSuffix(name, ".json") {
+			http.NotFound(w, r)
+			return
+		}
+		var opts analysis.RelevanceOptions
+		for param, threshold := range map[string]*float64{"near-constant": &opts.NearConstant, "id-like": &opts.IDLike} {
+			if value := r.URL.Query().Get(param); value != "" {
+				var err error
+				if *threshold, err = strconv.ParseFloat(value, 64); err != nil {
+					http.Error(w, fmt.Sprintf("parsing %s: %v", param, err), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+		sampleData, err := ioutil.ReadFile(filepath.Join("out", name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var sample parse.Sample
+		if err = json.Unmarshal(sampleData, &sample); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		relevance, err := analysis.NewRelevance(sample, opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(relevance)
+	})
 	http.Handle("/", fs)
 	http.Handle("/tree/", http.StripPrefix("/tree/", fsTree))
 
@@ -41,3 +123,8 @@ func main() {
 type TreeItem struct {
 	Filename string
 }
+
+// ProfileItem names a dataset profile in the out folder, served under /tree/
+type ProfileItem struct {
+	Filename string
+}
@@ -0,0 +1,140 @@
+function loadJSON(path, success, error) {
+    var xhr = new XMLHttpRequest();
+    xhr.onreadystatechange = function () {
+        if (xhr.readyState === XMLHttpRequest.DONE) {
+            if (xhr.status === 200) {
+                if (success)
+                    success(JSON.parse(xhr.responseText));
+            } else {
+                if (error)
+                    error(xhr);
+            }
+        }
+    };
+    xhr.open("GET", path, true);
+    xhr.send();
+}
+
+function listProfiles() {
+    loadJSON('/api/list/profiles',
+        function (files) {
+            if (files && files.length > 0) {
+                showProfile(files[0].Filename);
+                const btnGroup = document.querySelector('div.btn-group');
+                for (const file of files) {
+                    const buttonEle = document.createElement("button");
+                    buttonEle.onclick = function () { showProfile(file.Filename); };
+                    buttonEle.innerText = file.Filename;
+                    btnGroup.appendChild(buttonEle);
+                }
+            }
+        },
+        function (xhr) { console.error(xhr); }
+    );
+}
+
+function showProfile(filename) {
+    loadJSON(filename,
+        function (profile) {
+            renderProfile(document.getElementById('oak-profile'), profile);
+        },
+        function (xhr) { console.error(xhr); }
+    );
+}
+
+function renderProfile(container, profile) {
+    container.innerHTML = '';
+    addHeading(container, profile.Examples + ' examples of weight ' + profile.Weight);
+    const targets = (profile.Targets || []).map(t => t.Value);
+    if (profile.Target) {
+        addHeading(container, 'target ' + profile.RealTarget);
+        addSummary(container, profile.Target);
+    } else {
+        addHeading(container, 'targets');
+        addTable(container, ['target', 'weight', 'share'],
+            profile.Targets.map(t => [t.Value, t.Weight, percent(t.Share)]));
+    }
+    for (const attr of profile.Attributes) {
+        addHeading(container, attr.Name + (attr.Real ? ' (real)' : attr.Ordinal ? ' (ordinal)' : ''));
+        if (attr.Summary) {
+            addSummary(container, attr.Summary);
+            continue;
+        }
+        const headings = ['value', 'weight', 'share'].concat(profile.Target ? ['target mean'] : targets);
+        addTable(container, headings, attr.Values.map(function (v) {
+            const row = [v.Value, v.Weight, percent(v.Share)];
+            if (profile.Target) {
+                row.push((v.TargetMean || 0).toPrecision(4));
+            } else {
+                targets.forEach(t => row.push(v.ByTarget ? v.ByTarget[t] || 0 : 0));
+            }
+            return row;
+        }));
+    }
+    addGroups(container, 'duplicate examples', profile.Duplicates);
+    addGroups(container, 'conflicting examples', profile.Conflicts);
+}
+
+function addHeading(container, text) {
+    const heading = document.createElement('h3');
+    heading.innerText = text;
+    container.appendChild(heading);
+}
+
+function addTable(container, headings, rows) {
+    const table = document.createElement('table');
+    table.className = 'importance';
+    const header = table.insertRow();
+    for (const heading of headings) {
+        const th = document.createElement('th');
+        th.innerText = heading;
+        header.appendChild(th);
+    }
+    for (const values of rows) {
+        const row = table.insertRow();
+        values.forEach((value, i) => {
+            const cell = row.insertCell();
+            if (value instanceof Node) {
+                cell.appendChild(value);
+            } else {
+                cell.innerText = value;
+            }
+            if (i > 0) {
+                cell.className = 'number';
+            }
+        });
+    }
+    container.appendChild(table);
+}
+
+function addSummary(container, summary) {
+    addTable(container, ['min', 'max', 'mean', 'std dev', 'median'],
+        [[summary.Min, summary.Max, summary.Mean, summary.StdDev, summary.Median].map(x => x.toPrecision(4))]);
+    const most = Math.max(...summary.Histogram.map(b => b.Weight));
+    addTable(container, ['bin', 'weight', ''], summary.Histogram.map(function (b) {
+        const bar = document.createElement('div');
+        bar.className = 'bar';
+        bar.style.width = (most > 0 ? 200 * b.Weight / most : 0) + 'px';
+        return [b.Low.toPrecision(4) + ' to ' + b.High.toPrecision(4), b.Weight, bar];
+    }));
+}
+
+function addGroups(container, title, groups) {
+    groups = groups || [];
+    addHeading(container, title + ': ' + groups.length + ' groups');
+    if (groups.length === 0) {
+        return;
+    }
+    // groups of parsed examples know their lines in the data file
+    addTable(container, ['values', 'where', 'targets'], groups.map(g => [
+        g.Values.join(', '),
+        g.Lines ? 'lines ' + g.Lines.join(', ') : 'examples ' + g.Examples.join(', '),
+        Object.keys(g.Targets).sort().map(t => t + ': ' + g.Targets[t]).join(', ')
+    ]));
+}
+
+function percent(share) {
+    return (share * 100).toFixed(1) + '%';
+}
+
+listProfiles();
@@ -37,7 +37,7 @@ function convertTree(root) {
 function convertNode(node) {
     let newNode = {
         text: {
-            filter: node.FilterValue,
+            filter: node.FilterOp ? node.FilterOp + ' ' + node.FilterValue : node.FilterValue,
             label: node.Label
         },
         HTMLclass: node.Terminal ? 'blue' : 'light-gray'
@@ -76,4 +76,41 @@ function showFile(filename) {
         },
         function (xhr) { console.error(xhr); }
     );
+    const table = document.getElementById('oak-importance');
+    table.innerHTML = '';
+    loadJSON('/api/importance/' + filename.split('/').pop(),
+        function (importances) {
+            showImportance(table, importances || []);
+        },
+        function (xhr) { console.error(xhr); }
+    );
+}
+
+function showImportance(table, importances) {
+    // permutation importance is only known when the importance command measured it
+    const permuted = importances.some(imp => 'Permutation' in imp);
+    const headings = ['attribute', 'gain', 'share', 'splits'];
+    if (permuted) {
+        headings.push('permutation', 'std dev');
+    }
+    const header = table.insertRow();
+    for (const heading of headings) {
+        const th = document.createElement('th');
+        th.innerText = heading;
+        header.appendChild(th);
+    }
+    for (const imp of importances) {
+        const cells = [imp.Attribute, imp.Gain.toFixed(3), (imp.Share * 100).toFixed(1) + '%', imp.Splits];
+        if (permuted) {
+            cells.push(imp.Permutation.toFixed(3), imp.PermutationStdDev.toFixed(3));
+        }
+        const row = table.insertRow();
+        cells.forEach((value, i) => {
+            const cell = row.insertCell();
+            cell.innerText = value;
+            if (i > 0) {
+                cell.className = 'number';
+            }
+        });
+    }
 }
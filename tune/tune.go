@@ -0,0 +1,368 @@
+// Package tune searches the build options of a tree, such as its depth, how it
+// splits and how it is pruned, for the setting that scores best by cross-validation.
+package tune
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/evaluate"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// DefaultFolds is the number of cross-validation folds when Options does not give one.
+const DefaultFolds = 5
+
+// Metric names what the scores of a search measure.
+type Metric string
+
+const (
+	// Accuracy is the fraction of the test weight classified correctly, higher being better.
+	Accuracy Metric = "accuracy"
+	// Cost is the cost per unit of test weight of the predictions by the options'
+	// cost matrix, lower being better.
+	Cost Metric = "cost"
+	// RMSE is the root mean squared error of the predictions of a real target,
+	// lower being better.
+	RMSE Metric = "rmse"
+)
+
+// better reports whether score a is better than score b.
+func (m Metric) better(a, b float64) bool {
+	if m == Accuracy {
+		return a > b
+	}
+	return a < b
+}
+
+// Params are the build options a search varies.
+type Params struct {
+	MaxDepth     int
+	BinarySplits bool
+	Criterion    analysis.Criterion `json:",omitempty"`
+	// Prune is the fraction of each target's training examples held out to prune
+	// the tree with, zero for no pruning
+	Prune float64
+}
+
+// Options returns the base options with the parameters in place of their own.
+func (p Params) Options(base analysis.Options) analysis.Options {
+	base.MaxDepth = p.MaxDepth
+	base.BinarySplits = p.BinarySplits
+	base.Criterion = p.Criterion
+
+	return base
+}
+
+func (p Params) String() string {
+	s := "unlimited depth"
+	if p.MaxDepth > 0 {
+		s = fmt.Sprintf("depth %d", p.MaxDepth)
+	}
+	if p.BinarySplits {
+		s += ", binary splits"
+	}
+	if p.Criterion != "" {
+		s += fmt.Sprintf(", %s", p.Criterion)
+	}
+	if p.Prune > 0 {
+		s += fmt.Sprintf(", prune %g", p.Prune)
+	}
+
+	return s
+}
+
+// Space lists the values a search tries for each parameter. A parameter with no
+// values keeps its zero value, so the zero Space holds only the default options.
+type Space struct {
+	MaxDepth     []int
+	BinarySplits []bool
+	// Criterion is only searched for a real target, as it does not apply otherwise
+	Criterion []analysis.Criterion
+	Prune     []float64
+}
+
+// Grid returns every combination of the space's values, varying the last
+// parameter fastest.
+func (s Space) Grid() []Params {
+	depths := s.MaxDepth
+	if len(depths) == 0 {
+		depths = []int{0}
+	}
+	binarySplits := s.BinarySplits
+	if len(binarySplits) == 0 {
+		binarySplits = []bool{false}
+	}
+	criteria := s.Criterion
+	if len(criteria) == 0 {
+		criteria = []analysis.Criterion{""}
+	}
+	prunes := s.Prune
+	if len(prunes) == 0 {
+		prunes = []float64{0}
+	}
+	var grid []Params
+	for _, depth := range depths {
+		for _, binary := range binarySplits {
+			for _, criterion := range criteria {
+				for _, prune := range prunes {
+					grid = append(grid, Params{MaxDepth: depth, BinarySplits: binary, Criterion: criterion, Prune: prune})
+				}
+			}
+		}
+	}
+
+	return grid
+}
+
+// Random returns n different combinations of the space's values chosen at random,
+// or every combination in random order when the space has no more than n.
+func (s Space) Random(n int, rng *rand.Rand) []Params {
+	grid := s.Grid()
+	rng.Shuffle(len(grid), func(i, j int) { grid[i], grid[j] = grid[j], grid[i] })
+	if n < len(grid) {
+		grid = grid[:n]
+	}
+
+	return grid
+}
+
+// Options controls a search.
+type Options struct {
+	// Base holds the build options every candidate shares, such as class weights or
+	// costs. Each candidate's parameters replace its own.
+	Base analysis.Options
+	// Folds is the number of cross-validation folds, DefaultFolds when zero.
+	Folds int
+	// Random is the number of candidates drawn at random from the space, or zero to
+	// try every combination.
+	Random int
+	// Seed seeds the random draw of candidates, the folds and the pruning holdouts,
+	// so that a search with the same seed scores the same.
+	Seed int64
+	// Workers is the number of trees built concurrently. Zero or one builds serially.
+	// The report is the same however many workers are used.
+	Workers int
+}
+
+// Result is the cross-validated score of one candidate.
+type Result struct {
+	Params Params
+	// Scores holds the score of the tree built for each fold
+	Scores []float64
+	Mean   float64
+	StdDev float64
+}
+
+// Report is the outcome of a search.
+type Report struct {
+	Metric Metric
+	Folds  int
+	Seed   int64
+	// Leaderboard holds the result of every candidate, best mean score first.
+	// Candidates with the same mean keep the order they were tried in.
+	Leaderboard []Result
+	// Best is the parameters of the first candidate of the leaderboard
+	Best Params
+}
+
+// Search scores every candidate of the space by building a tree for each fold of
+// the sample with the candidate's options and testing it on the fold's own examples.
+// It stops with the context's error when the context is cancelled, and with the
+// first error any tree gives.
+func Search(ctx context.Context, sample parse.Sample, space Space, opts Options) (*Report, error) {
+	if opts.Folds < 0 || opts.Random < 0 || opts.Workers < 0 {
+		return nil, fmt.Errorf("folds %d, random candidates %d and workers %d must not be negative",
+			opts.Folds, opts.Random, opts.Workers)
+	}
+	if opts.Folds == 0 {
+		opts.Folds = DefaultFolds
+	}
+	for _, prune := range space.Prune {
+		if prune < 0 || prune >= 1 {
+			return nil, fmt.Errorf("prune fraction %v is not at least zero and less than one", prune)
+		}
+		if prune > 0 && sample.RealTarget != "" {
+			return nil, fmt.Errorf("pruning does not apply to the real target %s", sample.RealTarget)
+		}
+	}
+	metric := Accuracy
+	switch {
+	case sample.RealTarget != "":
+		metric = RMSE
+	case opts.Base.Costs != nil:
+		metric = Cost
+	}
+	if sample.RealTarget == "" {
+		space.Criterion = nil
+	}
+	candidates := space.Grid()
+	if opts.Random > 0 {
+		candidates = space.Random(opts.Random, rand.New(rand.NewSource(opts.Seed)))
+	}
+	trains, tests, err := evaluate.KFold(sample, opts.Folds, rand.New(rand.NewSource(opts.Seed)))
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([][]float64, len(candidates))
+	for c := range scores {
+		scores[c] = make([]float64, opts.Folds)
+	}
+	if err = scoreAll(ctx, opts.Workers, len(candidates)*opts.Folds, func(ctx context.Context, job int) error {
+		c, f := job/opts.Folds, job%opts.Folds
+		// every candidate prunes a fold with the same holdout so that they compete on equal terms
+		tree, err := Build(ctx, trains[f], opts.Base, candidates[c], opts.Seed+int64(f)+1)
+		if err != nil {
+			return fmt.Errorf("building %s for fold %d: %w", candidates[c], f+1, err)
+		}
+		if scores[c][f], err = score(tree, tests[f], metric, opts.Base.Costs); err != nil {
+			return fmt.Errorf("scoring %s for fold %d: %w", candidates[c], f+1, err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	r := &Report{Metric: metric, Folds: opts.Folds, Seed: opts.Seed}
+	for c, p := range candidates {
+		mean, stdDev := meanStdDev(scores[c])
+		r.Leaderboard = append(r.Leaderboard, Result{Params: p, Scores: scores[c], Mean: mean, StdDev: stdDev})
+	}
+	sort.SliceStable(r.Leaderboard, func(i, j int) bool {
+		return metric.better(r.Leaderboard[i].Mean, r.Leaderboard[j].Mean)
+	})
+	r.Best = r.Leaderboard[0].Params
+
+	return r, nil
+}
+
+// Build builds a tree from the sample with the parameters in place of the base
+// options' own. When the parameters prune, the tree is built from the rest of the
+// sample and pruned with a holdout drawn with the seed.
+func Build(ctx context.Context, sample parse.Sample, base analysis.Options, p Params, seed int64) (analysis.Node, error) {
+	var validation parse.Sample
+	if p.Prune > 0 {
+		var err error
+		sample, validation, err = evaluate.Holdout(sample, p.Prune, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			return analysis.Node{}, err
+		}
+	}
+	tree, err := analysis.BuildTreeContext(ctx, sample, p.Options(base))
+	if err != nil {
+		return tree, err
+	}
+	if p.Prune > 0 {
+		if _, err = tree.Prune(validation, base.Costs); err != nil {
+			return tree, err
+		}
+	}
+
+	return tree, nil
+}
+
+// scoreAll runs every job on up to workers goroutines. The first error cancels the
+// context the other jobs run with and is returned once they stop.
+func scoreAll(ctx context.Context, workers, numJobs int, run func(ctx context.Context, job int) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if err := run(ctx, job); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+	for job := 0; job < numJobs; job++ {
+		select {
+		case jobs <- job:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return ctx.Err()
+}
+
+func score(tree analysis.Node, test parse.Sample, metric Metric, costs *analysis.CostMatrix) (float64, error) {
+	if metric == RMSE {
+		residuals, err := evaluate.Regression(tree, test)
+		if err != nil {
+			return 0, err
+		}
+		return residuals.RMSE(), nil
+	}
+	confusion, err := evaluate.Tree(tree, test)
+	if err != nil {
+		return 0, err
+	}
+	if metric == Accuracy {
+		return confusion.Accuracy(), nil
+	}
+	cost, err := confusion.Cost(costs)
+	if err != nil {
+		return 0, err
+	}
+	if total := confusion.Total(); total > 0 {
+		cost /= total
+	}
+
+	return cost, nil
+}
+
+func meanStdDev(scores []float64) (mean, stdDev float64) {
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(len(scores))
+	for _, s := range scores {
+		stdDev += (s - mean) * (s - mean)
+	}
+
+	return mean, math.Sqrt(stdDev / float64(len(scores)))
+}
+
+// String renders the leaderboard with a row per candidate, best first.
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d candidates scored by %d-fold cross-validation\n", len(r.Leaderboard), r.Folds)
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "rank\tmean %s\tstd dev\tparameters\t\n", r.Metric)
+	for i, result := range r.Leaderboard {
+		fmt.Fprintf(tw, "%d\t%.4f\t%.4f\t%s\t\n", i+1, result.Mean, result.StdDev, result.Params)
+	}
+	tw.Flush()
+
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package tune
+
+import (
+	"context"
+	"github.com/PaluMacil/decisive-oak/analysis"
+	"github.com/PaluMacil/decisive-oak/parse"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	space := Space{MaxDepth: []int{1, 2, 0}, BinarySplits: []bool{false, true}, Prune: []float64{0, 0.25}}
+	serial, err := Search(context.Background(), sample, space, Options{Folds: 4, Seed: 3})
+	if err != nil {
+		t.Fatalf("searching: %v", err)
+	}
+	if serial.Metric != Accuracy || len(serial.Leaderboard) != 12 {
+		t.Fatalf("expected 12 candidates scored by accuracy, got %d by %s", len(serial.Leaderboard), serial.Metric)
+	}
+	for i, result := range serial.Leaderboard {
+		if len(result.Scores) != 4 {
+			t.Errorf("expected a score for each of 4 folds, got %v", result.Scores)
+		}
+		if i > 0 && result.Mean > serial.Leaderboard[i-1].Mean {
+			t.Errorf("expected the leaderboard to be sorted best first, got %v after %v", result.Mean, serial.Leaderboard[i-1].Mean)
+		}
+	}
+	if serial.Best != serial.Leaderboard[0].Params {
+		t.Errorf("expected the best parameters to lead, got %s", serial.Best)
+	}
+	// a stump cannot tell soft lenses from hard ones, which a deeper tree can
+	if serial.Best.MaxDepth == 1 {
+		t.Errorf("expected a deeper tree to score best, got %s", serial.Best)
+	}
+	parallel, err := Search(context.Background(), sample, space, Options{Folds: 4, Seed: 3, Workers: 4})
+	if err != nil {
+		t.Fatalf("searching in parallel: %v", err)
+	}
+	if !reflect.DeepEqual(serial, parallel) {
+		t.Errorf("expected the same report with workers, got\n%s\nand\n%s", serial, parallel)
+	}
+
+	random, err := Search(context.Background(), sample, space, Options{Folds: 4, Seed: 3, Random: 5})
+	if err != nil {
+		t.Fatalf("searching at random: %v", err)
+	}
+	seen := make(map[Params]bool)
+	for _, result := range random.Leaderboard {
+		if seen[result.Params] {
+			t.Errorf("expected different candidates, got %s twice", result.Params)
+		}
+		seen[result.Params] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected 5 random candidates, got %d", len(seen))
+	}
+
+	tree, err := Build(context.Background(), sample, analysis.Options{}, serial.Best, serial.Seed)
+	if err != nil {
+		t.Fatalf("building the best tree: %v", err)
+	}
+	if tree.Label == "" && len(tree.Children) == 0 {
+		t.Errorf("expected a tree, got %v", tree)
+	}
+}
+
+func TestSearch_Regression(t *testing.T) {
+	analysis.Output = ioutil.Discard
+	defer func() { analysis.Output = os.Stdout }()
+	schema, err := parse.SchemaFromFile("../data/fishing-catch.schema.json")
+	if err != nil {
+		t.Fatalf("failed reading schema: %v", err)
+	}
+	sample, err := parse.FromFileWithSchema("../data/fishing-catch.csv", schema, parse.ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed parsing file fishing-catch.csv: %v", err)
+	}
+	space := Space{MaxDepth: []int{1, 2}, Criterion: []analysis.Criterion{analysis.Variance, analysis.MAE}}
+	report, err := Search(context.Background(), sample, space, Options{Folds: 3})
+	if err != nil {
+		t.Fatalf("searching: %v", err)
+	}
+	if report.Metric != RMSE || len(report.Leaderboard) != 4 {
+		t.Errorf("expected 4 candidates scored by rmse, got %d by %s", len(report.Leaderboard), report.Metric)
+	}
+	if _, err = Search(context.Background(), sample, Space{Prune: []float64{0.2}}, Options{}); err == nil {
+		t.Error("expected an error for pruning a regression tree")
+	}
+}
+
+func TestSearch_Canceled(t *testing.T) {
+	sample, err := parse.FromFile("../data/contact-lenses.data.txt")
+	if err != nil {
+		t.Fatalf("failed parsing file contact-lenses.data.txt: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = Search(ctx, sample, Space{MaxDepth: []int{1, 2}}, Options{Workers: 2}); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+func TestSpace_Random(t *testing.T) {
+	space := Space{MaxDepth: []int{1, 2}, BinarySplits: []bool{false, true}}
+	if n := len(space.Random(10, rand.New(rand.NewSource(1)))); n != 4 {
+		t.Errorf("expected the whole grid of 4 when asking for more, got %d", n)
+	}
+}